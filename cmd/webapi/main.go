@@ -6,6 +6,7 @@ All API requests are handled by the API web server, while debug variables (/debu
 Usage:
 
 	webapi [flags]
+	webapi [flags] migrate status|up [steps]|down [steps]
 
 Flags and configurations are automatically managed by the code in `service/api/config/config.go`.
 
@@ -18,7 +19,8 @@ Return values (exit codes):
 		The program terminated due to an error.
 
 Note that this program will automatically update the database schema to the latest version available.
-The schema is embedded in the executable during the build process.
+The schema migrations are embedded in the executable during the build process, and the `migrate` subcommand
+can be used to inspect, apply or revert them without starting the servers.
 */
 
 package main
@@ -33,7 +35,6 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"strings"
 	"syscall"
 
 	"github.com/ardanlabs/conf"
@@ -97,13 +98,33 @@ func run() error {
 		db.Close()
 	}()
 
-	appDatabase, err := database.New(db, config.Database.MigrationsPath)
+	if config.Args.Num(0) == "migrate" {
+		return runMigrate(db, config.Args[1:], logger)
+	}
+
+	appDatabase, err := database.New(db)
 
 	if err != nil {
 		logger.WithError(err).Error("failed to create AppDatabase instance")
 		return fmt.Errorf("creating AppDatabase instance: %w", err)
 	}
 
+	logger.Infof("initializing %s blob storage", config.Storage.Backend)
+
+	store, err := newBlobStore(config)
+
+	if err != nil {
+		logger.WithError(err).Error("failed to initialize blob storage")
+		return fmt.Errorf("initializing blob storage: %w", err)
+	}
+
+	signer, err := newURLSigner(config, logger)
+
+	if err != nil {
+		logger.WithError(err).Error("failed to initialize URL signer")
+		return fmt.Errorf("initializing URL signer: %w", err)
+	}
+
 	logger.Info("initializing API server")
 
 	shutdown := make(chan os.Signal, 1)
@@ -112,8 +133,18 @@ func run() error {
 	serverErrors := make(chan error, 1)
 
 	router, err := api.New(api.Config{
-		Logger:   logger,
-		Database: appDatabase,
+		Logger:      logger,
+		Database:    appDatabase,
+		Store:       store,
+		Signer:      signer,
+		Attachments: newAttachmentPolicy(config),
+		SessionTTL:  config.Auth.SessionTTL,
+		EditWindow:  config.Messages.EditWindow,
+
+		TransferTimeout:  config.Web.TransferTimeout,
+		DispatchInterval: config.Messages.DispatchInterval,
+
+		ReactionDetailsMaxMembers: config.Messages.ReactionDetailsMaxMembers,
 	})
 
 	if err != nil {
@@ -121,17 +152,7 @@ func run() error {
 		return fmt.Errorf("creating the API server instance: %w", err)
 	}
 
-	baseHandler := router.Handler()
-
-	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.HasPrefix(r.URL.Path, "/uploads/") {
-			http.StripPrefix("/uploads/", http.FileServer(http.Dir("./tmp/uploads"))).ServeHTTP(w, r)
-			return
-		}
-		baseHandler.ServeHTTP(w, r)
-	})
-
-	handler, err = setupWebUI(handler)
+	handler, err := setupWebUI(router.Handler())
 
 	if err != nil {
 		logger.WithError(err).Error("failed to embed WebUI dist/ directory")
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ardanlabs/conf"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/sirupsen/logrus"
+)
+
+func runMigrate(db *sql.DB, args conf.Args, logger *logrus.Logger) error {
+	switch args.Num(0) {
+	case "status":
+		statuses, err := database.MigrationStatuses(db)
+		if err != nil {
+			return fmt.Errorf("reading migration status: %w", err)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = globaltime.Format(*status.AppliedAt)
+			} else if !status.Supported {
+				appliedAt = "skipped (requires " + strings.Join(status.Requires, ", ") + ")"
+			}
+
+			fmt.Fprintf(writer, "%03d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+
+		return writer.Flush()
+
+	case "up":
+		steps, err := parseMigrationSteps(args.Num(1), 0)
+		if err != nil {
+			return err
+		}
+
+		applied, err := database.MigrateUp(db, steps)
+		for _, migration := range applied {
+			logger.Infof("applied migration %03d_%s", migration.Version, migration.Name)
+		}
+
+		if err != nil {
+			return fmt.Errorf("migrating up: %w", err)
+		}
+
+		if len(applied) == 0 {
+			logger.Info("database schema is up to date")
+		}
+
+		return nil
+
+	case "down":
+		steps, err := parseMigrationSteps(args.Num(1), 1)
+		if err != nil {
+			return err
+		}
+
+		reverted, err := database.MigrateDown(db, steps)
+		for _, migration := range reverted {
+			logger.Infof("reverted migration %03d_%s", migration.Version, migration.Name)
+		}
+
+		if err != nil {
+			return fmt.Errorf("migrating down: %w", err)
+		}
+
+		if len(reverted) == 0 {
+			logger.Info("no migrations to revert")
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("usage: webapi migrate status|up [steps]|down [steps]")
+	}
+}
+
+func parseMigrationSteps(arg string, defaultSteps int) (int, error) {
+	if arg == "" {
+		return defaultSteps, nil
+	}
+
+	steps, err := strconv.Atoi(arg)
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("invalid number of steps %q", arg)
+	}
+
+	return steps, nil
+}
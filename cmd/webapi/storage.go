@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/evaevangelisti/wasatext/service/config"
+	"github.com/evaevangelisti/wasatext/service/media"
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/sirupsen/logrus"
+)
+
+func newBlobStore(config config.WebAPIConfig) (storage.BlobStore, error) {
+	switch config.Storage.Backend {
+	case "local":
+		return storage.NewLocalStore(config.Storage.Root)
+
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:        config.Storage.S3.Endpoint,
+			Region:          config.Storage.S3.Region,
+			Bucket:          config.Storage.S3.Bucket,
+			AccessKeyID:     config.Storage.S3.AccessKeyID,
+			SecretAccessKey: config.Storage.S3.SecretAccessKey,
+			Prefix:          config.Storage.S3.Prefix,
+		})
+
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", config.Storage.Backend)
+	}
+}
+
+func newAttachmentPolicy(config config.WebAPIConfig) media.Policy {
+	return media.Policy{
+		AllowedTypes: config.Attachments.AllowedTypes,
+		MaxSizes: map[string]int64{
+			media.KindImage:    config.Attachments.MaxImageSize,
+			media.KindAudio:    config.Attachments.MaxAudioSize,
+			media.KindVideo:    config.Attachments.MaxVideoSize,
+			media.KindDocument: config.Attachments.MaxDocumentSize,
+		},
+		MaxTotalSize: config.Attachments.MaxTotalSize,
+	}
+}
+
+func newURLSigner(config config.WebAPIConfig, logger *logrus.Logger) (*storage.URLSigner, error) {
+	secret := []byte(config.Storage.SigningKey)
+
+	if len(secret) == 0 {
+		logger.Warning("no storage signing key configured, signed URLs will not survive a restart")
+
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("generating signing key: %w", err)
+		}
+	}
+
+	return storage.NewURLSigner(secret, config.Storage.SignedURLTTL)
+}
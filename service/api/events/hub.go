@@ -0,0 +1,160 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+const subscriptionBufferSize = 64
+
+type Type string
+
+const (
+	MessageSent       Type = "message.sent"
+	MessageEdited     Type = "message.edited"
+	MessageDeleted    Type = "message.deleted"
+	MessageForwarded  Type = "message.forwarded"
+	MessagePinned     Type = "message.pinned"
+	MessageUnpinned   Type = "message.unpinned"
+	CommentAdded      Type = "comment.added"
+	CommentRemoved    Type = "comment.removed"
+	CommentUpdated    Type = "comment.updated"
+	MemberJoined      Type = "member.joined"
+	MemberLeft        Type = "member.left"
+	MessagesRead      Type = "messages.read"
+	MessagesDelivered Type = "messages.delivered"
+
+	ScheduledMessageFailed Type = "scheduled_message.failed"
+
+	ConversationCreated         Type = "conversation.created"
+	ConversationUpdated         Type = "conversation.updated"
+	ConversationSettingsUpdated Type = "conversation.settings_updated"
+)
+
+type Event struct {
+	Type           Type        `json:"type"`
+	ConversationID uuid.UUID   `json:"conversationId"`
+	Payload        interface{} `json:"payload,omitempty"`
+	OccurredAt     time.Time   `json:"occurredAt"`
+}
+
+type Subscription struct {
+	UserID uuid.UUID
+	Events chan Event
+}
+
+type Hub struct {
+	mutex         sync.RWMutex
+	subscriptions map[uuid.UUID]map[*Subscription]struct{}
+	closed        bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+func (hub *Hub) Subscribe(userID uuid.UUID) *Subscription {
+	subscription := &Subscription{
+		UserID: userID,
+		Events: make(chan Event, subscriptionBufferSize),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if hub.closed {
+		close(subscription.Events)
+		return subscription
+	}
+
+	if hub.subscriptions[userID] == nil {
+		hub.subscriptions[userID] = make(map[*Subscription]struct{})
+	}
+
+	hub.subscriptions[userID][subscription] = struct{}{}
+
+	return subscription
+}
+
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	hub.remove(subscription)
+}
+
+func (hub *Hub) Publish(recipientIDs []uuid.UUID, eventType Type, conversationID uuid.UUID, payload interface{}) {
+	event := Event{
+		Type:           eventType,
+		ConversationID: conversationID,
+		Payload:        payload,
+		OccurredAt:     globaltime.Now(),
+	}
+
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if hub.closed {
+		return
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(recipientIDs))
+
+	for _, recipientID := range recipientIDs {
+		if _, ok := seen[recipientID]; ok {
+			continue
+		}
+
+		seen[recipientID] = struct{}{}
+
+		for subscription := range hub.subscriptions[recipientID] {
+			select {
+			case subscription.Events <- event:
+			default:
+				hub.remove(subscription)
+			}
+		}
+	}
+}
+
+func (hub *Hub) Close() {
+	hub.mutex.Lock()
+	defer hub.mutex.Unlock()
+
+	if hub.closed {
+		return
+	}
+
+	hub.closed = true
+
+	for _, subscriptions := range hub.subscriptions {
+		for subscription := range subscriptions {
+			close(subscription.Events)
+		}
+	}
+
+	hub.subscriptions = nil
+}
+
+func (hub *Hub) remove(subscription *Subscription) {
+	subscriptions, ok := hub.subscriptions[subscription.UserID]
+	if !ok {
+		return
+	}
+
+	if _, ok := subscriptions[subscription]; !ok {
+		return
+	}
+
+	delete(subscriptions, subscription)
+	close(subscription.Events)
+
+	if len(subscriptions) == 0 {
+		delete(hub.subscriptions, subscription.UserID)
+	}
+}
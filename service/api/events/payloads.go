@@ -0,0 +1,55 @@
+package events
+
+import (
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/google/uuid"
+)
+
+type MessageDeletedPayload struct {
+	MessageID uuid.UUID `json:"messageId"`
+	Scope     string    `json:"scope"`
+}
+
+type MessageUnpinnedPayload struct {
+	MessageID uuid.UUID `json:"messageId"`
+}
+
+type CommentAddedPayload struct {
+	MessageID uuid.UUID      `json:"messageId"`
+	Comment   models.Comment `json:"comment"`
+}
+
+type CommentRemovedPayload struct {
+	MessageID uuid.UUID `json:"messageId"`
+	CommentID uuid.UUID `json:"commentId"`
+	UserID    uuid.UUID `json:"userId"`
+	Emoji     string    `json:"emoji"`
+}
+
+type CommentUpdatedPayload struct {
+	MessageID     uuid.UUID      `json:"messageId"`
+	PreviousEmoji string         `json:"previousEmoji"`
+	Comment       models.Comment `json:"comment"`
+}
+
+type MemberJoinedPayload struct {
+	Member models.Member `json:"member"`
+}
+
+type MemberLeftPayload struct {
+	UserID uuid.UUID `json:"userId"`
+}
+
+type MessagesReadPayload struct {
+	UserID     uuid.UUID   `json:"userId"`
+	MessageIDs []uuid.UUID `json:"messageIds"`
+	ReadAt     time.Time   `json:"readAt"`
+}
+
+type MessagesDeliveredPayload struct {
+	UserID      uuid.UUID   `json:"userId"`
+	MessageIDs  []uuid.UUID `json:"messageIds"`
+	DeliveredAt time.Time   `json:"deliveredAt"`
+}
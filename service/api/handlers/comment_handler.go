@@ -17,7 +17,7 @@ type CommentHandler struct {
 }
 
 type CommentMessageRequest struct {
-	Emoji string `json:"emoji" validate:"required"`
+	Emoji string `json:"emoji" validate:"required,max=32"`
 }
 
 func (handler *CommentHandler) CommentMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
@@ -67,6 +67,53 @@ func (handler *CommentHandler) CommentMessage(w http.ResponseWriter, r *http.Req
 	}
 }
 
+func (handler *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	commentID := ps.ByName("commentId")
+
+	cid, err := uuid.Parse(commentID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var request CommentMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	comment, err := handler.Service.UpdateComment(cid, auid, request.Emoji)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(comment); err != nil {
+		return
+	}
+}
+
 func (handler *CommentHandler) UncommentMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
 	if !ok {
@@ -3,13 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	stdErrors "errors"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/evaevangelisti/wasatext/service/api/middlewares"
+	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/api/services"
 	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
@@ -19,7 +18,12 @@ import (
 )
 
 type ConversationHandler struct {
-	Service *services.ConversationService
+	Service         *services.ConversationService
+	TransferTimeout time.Duration
+}
+
+type GetMyConversationsQuery struct {
+	Archived string `validate:"omitempty,oneof=true false"`
 }
 
 func (handler *ConversationHandler) GetMyConversations(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
@@ -35,7 +39,27 @@ func (handler *ConversationHandler) GetMyConversations(w http.ResponseWriter, r
 		return
 	}
 
-	conversations, err := handler.Service.GetConversationsByUserID(auid)
+	query := GetMyConversationsQuery{Archived: r.URL.Query().Get("archived")}
+
+	validate := validator.New()
+	if err := validate.Struct(query); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var archived *bool
+
+	if query.Archived != "" {
+		value, err := strconv.ParseBool(query.Archived)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+
+		archived = &value
+	}
+
+	conversations, err := handler.Service.GetConversationsByUserID(auid, archived)
 	if err != nil {
 		errors.WriteHTTPError(w, err)
 		return
@@ -159,9 +183,7 @@ func (handler *ConversationHandler) CreateConversation(w http.ResponseWriter, r
 			return
 		}
 	case "group":
-		members := append([]uuid.UUID{auid}, request.Members...)
-
-		groupConversation, err := handler.Service.CreateGroupConversation(request.Name, members)
+		groupConversation, err := handler.Service.CreateGroupConversation(request.Name, auid, request.Members)
 		if err != nil {
 			errors.WriteHTTPError(w, err)
 			return
@@ -229,6 +251,164 @@ func (handler *ConversationHandler) AddToGroup(w http.ResponseWriter, r *http.Re
 	}
 }
 
+func (handler *ConversationHandler) GetGroupInvites(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	invites, err := handler.Service.GetGroupInvites(cid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(invites); err != nil {
+		return
+	}
+}
+
+type CreateGroupInviteRequest struct {
+	ExpiresAt time.Time `json:"expiresAt" validate:"omitempty"`
+	MaxUses   int       `json:"maxUses" validate:"omitempty,min=1,max=100"`
+}
+
+func (handler *ConversationHandler) CreateGroupInvite(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var request CreateGroupInviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	invite, err := handler.Service.CreateGroupInvite(cid, auid, request.MaxUses, request.ExpiresAt)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(invite); err != nil {
+		return
+	}
+}
+
+func (handler *ConversationHandler) RevokeGroupInvite(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	inviteID := ps.ByName("inviteId")
+
+	iid, err := uuid.Parse(inviteID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.RevokeGroupInvite(cid, auid, iid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *ConversationHandler) JoinGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	token := ps.ByName("token")
+	if len(token) != 43 {
+		errors.WriteHTTPError(w, errors.ErrNotFound)
+		return
+	}
+
+	groupConversation, err := handler.Service.JoinGroupByInvite(token, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(groupConversation); err != nil {
+		return
+	}
+}
+
 type SetGroupNameRequest struct {
 	Name string `json:"name" validate:"required,min=1,max=50"`
 }
@@ -301,68 +481,365 @@ func (handler *ConversationHandler) SetGroupPhoto(w http.ResponseWriter, r *http
 		return
 	}
 
-	var dstFilename, dstPath string
+	if err := extendTransferDeadline(w, handler.TransferTimeout); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, utils.MaxImageSize+utils.MultipartOverhead)
+	if err := parseMultipartForm(r); err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	image, err := readImageUpload(r, "image")
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	groupConversation, err := handler.Service.UpdateGroupPhoto(cid, auid, image)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(groupConversation); err != nil {
+		return
+	}
+}
+
+func (handler *ConversationHandler) LeaveGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
 
-	if err := r.ParseMultipartForm(5 << 20); err != nil {
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
 		errors.WriteHTTPError(w, errors.ErrBadRequest)
 		return
 	}
 
-	file, header, err := r.FormFile("image")
-	if err == nil && file != nil {
-		defer file.Close()
+	err = handler.Service.RemoveMember(cid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
 
-		ext := strings.ToLower(filepath.Ext(header.Filename))
-		if ext != utils.ExtJPG && ext != utils.ExtJPEG && ext != utils.ExtPNG && ext != utils.ExtWEBP {
-			errors.WriteHTTPError(w, errors.ErrBadRequest)
-			return
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		dstFilename = uuid.New().String() + ext
-		dstPath = "./tmp/uploads/group-photos/" + dstFilename
+func (handler *ConversationHandler) RemoveFromGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if ps.ByName("userId") == "me" {
+		handler.LeaveGroup(w, r, ps)
+		return
 	}
 
-	photo := ""
-	if dstFilename != "" {
-		photo = "/uploads/group-photos/" + dstFilename
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
 	}
 
-	groupConversation, err := handler.Service.UpdateGroupPhoto(cid, auid, photo)
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	userID := ps.ByName("userId")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.KickMember(cid, auid, uid)
 	if err != nil {
 		errors.WriteHTTPError(w, err)
 		return
 	}
 
-	if file != nil && dstPath != "" {
-		dstDir := "./tmp/uploads/group-photos/"
-		if err := os.MkdirAll(dstDir, 0755); err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		dst, err := os.Create(dstPath)
-		if err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+type SetMemberRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
 
-		defer dst.Close()
+func (handler *ConversationHandler) SetMemberRole(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
 
-		if _, err := io.Copy(dst, file); err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	userID := ps.ByName("userId")
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var request SetMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	groupConversation, err := handler.Service.UpdateMemberRole(cid, auid, uid, request.Role)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(groupConversation); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+	}
+}
+
+type SetConversationSettingsRequest struct {
+	MutedUntil optionalTime `json:"mutedUntil"`
+	Archived   *bool        `json:"archived"`
+	Pinned     *bool        `json:"pinned"`
+}
+
+type optionalTime struct {
+	Set  bool
+	Time time.Time
+}
+
+func (t *optionalTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+
+	if string(data) == "null" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	return json.Unmarshal(data, &t.Time)
+}
+
+func (handler *ConversationHandler) SetConversationSettings(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var request SetConversationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	if !request.MutedUntil.Set && request.Archived == nil && request.Pinned == nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	update := models.ConversationSettingsUpdate{
+		Archived: request.Archived,
+		Pinned:   request.Pinned,
+	}
+
+	if request.MutedUntil.Set {
+		update.MutedUntil = &request.MutedUntil.Time
+	}
+
+	settings, err := handler.Service.UpdateConversationSettings(cid, auid, update)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(settings); err != nil {
+		return
+	}
+}
+
+type SetGroupPermissionsRequest struct {
+	Rename      string `json:"rename" validate:"required,oneof=members admins"`
+	ChangePhoto string `json:"changePhoto" validate:"required,oneof=members admins"`
+	AddMembers  string `json:"addMembers" validate:"required,oneof=members admins"`
+	Post        string `json:"post" validate:"required,oneof=members admins"`
+	Pin         string `json:"pin" validate:"required,oneof=members admins"`
+}
+
+func (handler *ConversationHandler) SetGroupPermissions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var request SetGroupPermissionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	groupConversation, err := handler.Service.UpdateGroupPermissions(cid, auid, models.GroupPermissions{
+		Rename:      request.Rename,
+		ChangePhoto: request.ChangePhoto,
+		AddMembers:  request.AddMembers,
+		Post:        request.Post,
+		Pin:         request.Pin,
+	})
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
 	if err = json.NewEncoder(w).Encode(groupConversation); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+	}
+}
+
+type PinMessageRequest struct {
+	MessageID uuid.UUID `json:"messageId" validate:"required"`
+}
+
+func (handler *ConversationHandler) PinMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
 		return
 	}
+
+	var request PinMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	pin, err := handler.Service.PinMessage(cid, auid, request.MessageID)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(pin); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+	}
 }
 
-func (handler *ConversationHandler) LeaveGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+func (handler *ConversationHandler) UnpinMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
 	if !ok {
 		errors.WriteHTTPError(w, errors.ErrUnauthorized)
@@ -383,8 +860,15 @@ func (handler *ConversationHandler) LeaveGroup(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	err = handler.Service.RemoveMember(cid, auid)
+	messageID := ps.ByName("messageId")
+
+	mid, err := uuid.Parse(messageID)
 	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	if err := handler.Service.UnpinMessage(cid, auid, mid); err != nil {
 		errors.WriteHTTPError(w, err)
 		return
 	}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/events"
+	"github.com/evaevangelisti/wasatext/service/api/middlewares"
+	"github.com/evaevangelisti/wasatext/service/api/services"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+)
+
+const eventStreamHeartbeatInterval = 25 * time.Second
+
+type EventHandler struct {
+	Hub            *events.Hub
+	MessageService *services.MessageService
+}
+
+func (handler *EventHandler) StreamEvents(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	subscription := handler.Hub.Subscribe(auid)
+	defer handler.Hub.Unsubscribe(subscription)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+
+			if event.Type == events.MessageSent || event.Type == events.MessageForwarded {
+				_ = handler.MessageService.MarkMessagesDelivered(event.ConversationID, auid)
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -3,30 +3,474 @@ package handlers
 import (
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
-	"os"
-	"path/filepath"
+	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/evaevangelisti/wasatext/service/api/middlewares"
 	"github.com/evaevangelisti/wasatext/service/api/services"
+	"github.com/evaevangelisti/wasatext/service/media"
 	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/julienschmidt/httprouter"
 )
 
 type MessageHandler struct {
-	Service *services.MessageService
+	Service         *services.MessageService
+	Attachments     media.Policy
+	TransferTimeout time.Duration
+}
+
+type GetMessagesQuery struct {
+	Before string `validate:"omitempty,max=128"`
+	Limit  int    `validate:"min=1,max=100"`
+}
+
+func (handler *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	query := GetMessagesQuery{Before: r.URL.Query().Get("before"), Limit: utils.MessagesPageDefaultLimit}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(query); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	page, err := handler.Service.GetMessagesByConversationID(cid, auid, query.Before, query.Limit)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		return
+	}
+}
+
+func (handler *MessageHandler) GetReplies(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	messageID := ps.ByName("messageId")
+
+	mid, err := uuid.Parse(messageID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	query := GetMessagesQuery{Before: r.URL.Query().Get("before"), Limit: utils.MessagesPageDefaultLimit}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(query); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	page, err := handler.Service.GetRepliesByMessageID(mid, auid, query.Before, query.Limit)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		return
+	}
+}
+
+type SearchMessagesQuery struct {
+	Q              string `validate:"required,min=1,max=100"`
+	ConversationID string `validate:"omitempty,uuid"`
+	From           string `validate:"omitempty,uuid"`
+	Before         string `validate:"omitempty,max=128"`
+	Limit          int    `validate:"min=1,max=100"`
+}
+
+func (handler *MessageHandler) SearchMessages(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	query := SearchMessagesQuery{
+		Q:              r.URL.Query().Get("q"),
+		ConversationID: r.URL.Query().Get("conversationId"),
+		From:           r.URL.Query().Get("from"),
+		Before:         r.URL.Query().Get("before"),
+		Limit:          utils.MessagesPageDefaultLimit,
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(query); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var cid, sid uuid.UUID
+
+	if query.ConversationID != "" {
+		cid, err = uuid.Parse(query.ConversationID)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	if query.From != "" {
+		sid, err = uuid.Parse(query.From)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	page, err := handler.Service.SearchMessages(auid, query.Q, cid, sid, query.Before, query.Limit)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		return
+	}
+}
+
+func (handler *MessageHandler) GetStarredMessages(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	query := GetMessagesQuery{Before: r.URL.Query().Get("before"), Limit: utils.MessagesPageDefaultLimit}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(query); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	page, err := handler.Service.GetStarredMessages(auid, query.Before, query.Limit)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(page); err != nil {
+		return
+	}
+}
+
+type StarMessageRequest struct {
+	MessageID uuid.UUID `json:"messageId" validate:"required"`
+}
+
+func (handler *MessageHandler) StarMessage(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	var request StarMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var validate = validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	starred, err := handler.Service.StarMessage(request.MessageID, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(starred); err != nil {
+		return
+	}
+}
+
+func (handler *MessageHandler) UnstarMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	messageID := ps.ByName("messageId")
+
+	mid, err := uuid.Parse(messageID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.UnstarMessage(mid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 type SendMessageRequest struct {
 	Content          string `validate:"omitempty,min=1,max=1000"`
 	ReplyToMessageID string `validate:"omitempty,uuid"`
+	SendAt           string `validate:"omitempty,max=25"`
+}
+
+func (handler *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	if err := extendTransferDeadline(w, handler.TransferTimeout); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	maxBodySize := handler.Attachments.MaxTotalSize + utils.MultipartOverhead
+	if r.ContentLength > maxBodySize {
+		errors.WriteHTTPError(w, errors.ErrPayloadTooLarge)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+
+	if err := parseMultipartForm(r); err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+
+	content := r.FormValue("content")
+	replyToMessageID := r.FormValue("replyToMessageId")
+	sendAt := r.FormValue("sendAt")
+
+	request := SendMessageRequest{Content: content, ReplyToMessageID: replyToMessageID, SendAt: sendAt}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var rtmid uuid.UUID
+
+	if replyToMessageID != "" {
+		rtmid, err = uuid.Parse(replyToMessageID)
+
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+	}
+
+	if sendAt != "" {
+		handler.scheduleMessage(w, r, cid, auid, content, rtmid, sendAt)
+		return
+	}
+
+	files, err := readAttachmentUploads(r, "file", handler.Attachments)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	images, err := readAttachmentUploads(r, "image", handler.Attachments)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	if len(images) > 0 {
+		if len(files) > 0 {
+			errors.WriteHTTPError(w, errors.ErrBadRequest)
+			return
+		}
+
+		for _, image := range images {
+			if image.Kind != media.KindImage {
+				errors.WriteHTTPError(w, errors.ErrBadRequest)
+				return
+			}
+		}
+
+		files = images
+	}
+
+	message, err := handler.Service.CreateMessage(cid, auid, content, files, rtmid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(message); err != nil {
+		return
+	}
 }
 
-func (handler *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+func (handler *MessageHandler) scheduleMessage(w http.ResponseWriter, r *http.Request, conversationID, userID uuid.UUID, content string, replyToMessageID uuid.UUID, sendAt string) {
+	if len(r.MultipartForm.File) > 0 {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	sendAtTime, err := globaltime.Parse(sendAt)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	scheduledMessage, err := handler.Service.ScheduleMessage(conversationID, userID, content, replyToMessageID, sendAtTime)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err = json.NewEncoder(w).Encode(scheduledMessage); err != nil {
+		return
+	}
+}
+
+func (handler *MessageHandler) GetScheduledMessages(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
 	if !ok {
 		errors.WriteHTTPError(w, errors.ErrUnauthorized)
@@ -47,87 +491,56 @@ func (handler *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := r.ParseMultipartForm(5 << 20); err != nil {
-		errors.WriteHTTPError(w, errors.ErrBadRequest)
+	scheduledMessages, err := handler.Service.GetScheduledMessages(cid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
 		return
 	}
 
-	content := r.FormValue("content")
-	replyToMessageID := r.FormValue("replyToMessageId")
-
-	request := SendMessageRequest{Content: content, ReplyToMessageID: replyToMessageID}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-	validate := validator.New()
-	if err := validate.Struct(request); err != nil {
-		errors.WriteHTTPError(w, errors.ErrBadRequest)
+	if err = json.NewEncoder(w).Encode(scheduledMessages); err != nil {
 		return
 	}
+}
 
-	var rtmid uuid.UUID
-
-	if replyToMessageID != "" {
-		rtmid, err = uuid.Parse(replyToMessageID)
-
-		if err != nil {
-			errors.WriteHTTPError(w, errors.ErrBadRequest)
-			return
-		}
+func (handler *MessageHandler) CancelScheduledMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
 	}
 
-	var dstFilename, dstPath string
-
-	file, header, err := r.FormFile("image")
-	if err == nil && file != nil {
-		defer file.Close()
-
-		ext := strings.ToLower(filepath.Ext(header.Filename))
-		if ext != utils.ExtJPG && ext != utils.ExtJPEG && ext != utils.ExtPNG && ext != utils.ExtWEBP {
-			errors.WriteHTTPError(w, errors.ErrBadRequest)
-			return
-		}
-
-		dstFilename = uuid.New().String() + ext
-		dstPath = "./tmp/uploads/attachments/" + dstFilename
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
 	}
 
-	attachment := ""
-	if dstFilename != "" {
-		attachment = "/uploads/attachments/" + dstFilename
-	}
+	conversationID := ps.ByName("conversationId")
 
-	message, err := handler.Service.CreateMessage(cid, auid, content, attachment, rtmid)
+	cid, err := uuid.Parse(conversationID)
 	if err != nil {
-		errors.WriteHTTPError(w, err)
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
 		return
 	}
 
-	if file != nil && dstPath != "" {
-		dstDir := "./tmp/uploads/attachments/"
-		if err := os.MkdirAll(dstDir, 0755); err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
-
-		dst, err := os.Create(dstPath)
-		if err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
-
-		defer dst.Close()
+	scheduledMessageID := ps.ByName("scheduledMessageId")
 
-		if _, err := io.Copy(dst, file); err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+	smid, err := uuid.Parse(scheduledMessageID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-
-	if err = json.NewEncoder(w).Encode(message); err != nil {
+	err = handler.Service.CancelScheduledMessage(cid, auid, smid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
 		return
 	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 type ForwardMessageRequest struct {
@@ -181,6 +594,50 @@ func (handler *MessageHandler) ForwardMessage(w http.ResponseWriter, r *http.Req
 	}
 }
 
+type ForwardMessagesRequest struct {
+	MessageIDs      []uuid.UUID `json:"messageIds" validate:"required,min=1,max=50,unique,dive,required"`
+	ConversationIDs []uuid.UUID `json:"conversationIds" validate:"required,min=1,max=20,unique,dive,required"`
+}
+
+func (handler *MessageHandler) ForwardMessages(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	var request ForwardMessagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var validate = validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	forwardedMessages, err := handler.Service.CreateForwardedMessages(auid, request.MessageIDs, request.ConversationIDs)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err = json.NewEncoder(w).Encode(forwardedMessages); err != nil {
+		return
+	}
+}
+
 type EditMessageRequest struct {
 	Content string `json:"content,omitempty" validate:"omitempty,min=1,max=1000"`
 }
@@ -232,6 +689,45 @@ func (handler *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+func (handler *MessageHandler) GetMessageRevisions(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	messageID := ps.ByName("messageId")
+
+	mid, err := uuid.Parse(messageID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	revisions, err := handler.Service.GetMessageRevisions(mid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(revisions); err != nil {
+		return
+	}
+}
+
+type DeleteMessageQuery struct {
+	Scope string `validate:"required,oneof=everyone me"`
+}
+
 func (handler *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
 	if !ok {
@@ -253,7 +749,127 @@ func (handler *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	err = handler.Service.DeleteMessage(mid, auid)
+	request := DeleteMessageQuery{Scope: r.URL.Query().Get("scope")}
+	if request.Scope == "" {
+		request.Scope = utils.DeleteScopeEveryone
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.DeleteMessage(mid, auid, request.Scope)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *MessageHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := extendTransferDeadline(w, handler.TransferTimeout); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	var auid uuid.UUID
+
+	if authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context()); ok {
+		var err error
+
+		auid, err = uuid.Parse(authenticatedUserID)
+		if err != nil {
+			errors.WriteHTTPError(w, errors.ErrUnauthorized)
+			return
+		}
+	}
+
+	messageID := ps.ByName("messageId")
+
+	mid, err := uuid.Parse(messageID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	index, err := strconv.Atoi(ps.ByName("index"))
+	if err != nil || index < 0 {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	attachment, blob, err := handler.Service.GetAttachment(mid, index, auid, query.Get("expires"), query.Get("signature"))
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	defer blob.Close()
+
+	filename := attachment.Filename
+	if filename == "" {
+		filename = "attachment" + path.Ext(attachment.URL)
+	}
+
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Disposition", contentDisposition(filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private")
+
+	if blob.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, blob); err != nil {
+		return
+	}
+}
+
+type MarkConversationReadRequest struct {
+	MessageID uuid.UUID `json:"messageId" validate:"required"`
+}
+
+func (handler *MessageHandler) MarkConversationRead(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	conversationID := ps.ByName("conversationId")
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var request MarkConversationReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	var validate = validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.MarkMessagesRead(cid, auid, request.MessageID)
 	if err != nil {
 		errors.WriteHTTPError(w, err)
 		return
@@ -261,3 +877,23 @@ func (handler *MessageHandler) DeleteMessage(w http.ResponseWriter, r *http.Requ
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func contentDisposition(filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7E || r == '"' || r == '\\' {
+			return '_'
+		}
+
+		return r
+	}, filename)
+
+	disposition := `attachment; filename="` + fallback + `"`
+
+	if fallback != filename {
+		if encoded := mime.FormatMediaType("attachment", map[string]string{"filename": filename}); encoded != "" {
+			disposition += "; " + strings.TrimPrefix(encoded, "attachment; ")
+		}
+	}
+
+	return disposition
+}
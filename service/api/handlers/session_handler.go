@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/evaevangelisti/wasatext/service/api/middlewares"
+	"github.com/evaevangelisti/wasatext/service/api/services"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+)
+
+type SessionHandler struct {
+	Service     *services.SessionService
+	UserService *services.UserService
+}
+
+type DoLoginRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=16"`
+	Password string `json:"password" validate:"omitempty,max=72"`
+}
+
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	if len(host) > 64 {
+		host = host[:64]
+	}
+
+	return host
+}
+
+func (handler *SessionHandler) DoLogin(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var request DoLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	user, created, err := handler.UserService.DoLogin(request.Username, request.Password, clientAddress(r))
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	session, err := handler.Service.CreateSession(user.ID)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if err = json.NewEncoder(w).Encode(session); err != nil {
+		return
+	}
+}
+
+func (handler *SessionHandler) DoLogout(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sessionID, ok := middlewares.GetSessionIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	err = handler.Service.RevokeSession(sid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *SessionHandler) GetMySessions(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sessionID, ok := middlewares.GetSessionIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sessions, err := handler.Service.GetSessionsByUserID(auid, sid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(sessions); err != nil {
+		return
+	}
+}
+
+func (handler *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sessionID := ps.ByName("sessionId")
+
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.RevokeSession(sid, auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	stdErrors "errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/middlewares"
+	"github.com/evaevangelisti/wasatext/service/api/services"
+	"github.com/evaevangelisti/wasatext/service/media"
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/evaevangelisti/wasatext/service/utils"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+)
+
+type UploadHandler struct {
+	Store           storage.BlobStore
+	Signer          *storage.URLSigner
+	MessageService  *services.MessageService
+	TransferTimeout time.Duration
+}
+
+func (handler *UploadHandler) GetUpload(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := extendTransferDeadline(w, handler.TransferTimeout); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	key := strings.TrimPrefix(ps.ByName("key"), "/")
+
+	private := strings.HasPrefix(key, storage.AttachmentsDir+"/")
+
+	if private {
+		if err := handler.authorizeAttachment(r, key); err != nil {
+			errors.WriteHTTPError(w, err)
+			return
+		}
+	}
+
+	blob, err := handler.Store.Get(key)
+	if err != nil {
+		if stdErrors.Is(err, storage.ErrNotFound) || stdErrors.Is(err, storage.ErrInvalidKey) {
+			errors.WriteHTTPError(w, errors.ErrNotFound)
+			return
+		}
+
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", blob.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if private {
+		w.Header().Set("Cache-Control", "private")
+	}
+
+	if blob.Size >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(blob.Size, 10))
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, blob); err != nil {
+		return
+	}
+}
+
+func (handler *UploadHandler) authorizeAttachment(r *http.Request, key string) error {
+	query := r.URL.Query()
+
+	if signature := query.Get("signature"); signature != "" {
+		if err := handler.Signer.Verify(key, query.Get("expires"), signature); err != nil {
+			return errors.ErrForbidden
+		}
+
+		return nil
+	}
+
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		return errors.ErrUnauthorized
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		return errors.ErrUnauthorized
+	}
+
+	return handler.MessageService.AuthorizeAttachment(storage.URL(key), auid)
+}
+
+func extendTransferDeadline(w http.ResponseWriter, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	controller := http.NewResponseController(w)
+	deadline := time.Now().Add(timeout)
+
+	if err := controller.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+
+	return controller.SetWriteDeadline(deadline)
+}
+
+func parseMultipartForm(r *http.Request) error {
+	if err := r.ParseMultipartForm(5 << 20); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if stdErrors.As(err, &maxBytesError) {
+			return errors.ErrPayloadTooLarge
+		}
+
+		return errors.ErrBadRequest
+	}
+
+	return nil
+}
+
+func readImageUpload(r *http.Request, field string) (*storage.Upload, error) {
+	file, _, err := r.FormFile(field)
+	if err != nil {
+		if stdErrors.Is(err, http.ErrMissingFile) {
+			return nil, nil
+		}
+
+		return nil, errors.ErrBadRequest
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, utils.MaxImageSize+1))
+	if err != nil || len(data) == 0 || len(data) > utils.MaxImageSize {
+		return nil, errors.ErrBadRequest
+	}
+
+	upload, err := storage.NewImageUpload(data)
+	if err != nil {
+		return nil, errors.ErrBadRequest
+	}
+
+	return upload, nil
+}
+
+func readAttachmentUploads(r *http.Request, field string, policy media.Policy) ([]*media.File, error) {
+	if r.MultipartForm == nil {
+		return nil, nil
+	}
+
+	headers := r.MultipartForm.File[field]
+
+	if len(headers) > utils.MaxMessageAttachments {
+		return nil, errors.ErrBadRequest
+	}
+
+	files := make([]*media.File, 0, len(headers))
+
+	for _, header := range headers {
+		file, err := readAttachmentUpload(header, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func readAttachmentUpload(header *multipart.FileHeader, policy media.Policy) (*media.File, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, errors.ErrBadRequest
+	}
+
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, policy.MaxSize()+1))
+	if err != nil || len(data) == 0 {
+		return nil, errors.ErrBadRequest
+	}
+
+	if int64(len(data)) > policy.MaxSize() {
+		return nil, errors.ErrPayloadTooLarge
+	}
+
+	attachment, err := media.Inspect(data, header.Filename)
+	if err != nil {
+		return nil, errors.ErrUnsupportedType
+	}
+
+	if err := policy.Check(attachment); err != nil {
+		if stdErrors.Is(err, media.ErrTooLarge) {
+			return nil, errors.ErrPayloadTooLarge
+		}
+
+		return nil, errors.ErrUnsupportedType
+	}
+
+	return attachment, nil
+}
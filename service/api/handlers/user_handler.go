@@ -2,13 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
-	"io"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"time"
 
 	"github.com/evaevangelisti/wasatext/service/api/middlewares"
+	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/api/services"
 	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
@@ -18,7 +16,8 @@ import (
 )
 
 type UserHandler struct {
-	Service *services.UserService
+	Service         *services.UserService
+	TransferTimeout time.Duration
 }
 
 type GetUsersQuery struct {
@@ -60,12 +59,24 @@ func (handler *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request, _ h
 	}
 }
 
-type DoLoginRequest struct {
+type SetMyUsernameRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=16"`
 }
 
-func (handler *UserHandler) DoLogin(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	var request DoLoginRequest
+func (handler *UserHandler) SetMyUserName(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	var request SetMyUsernameRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		errors.WriteHTTPError(w, errors.ErrBadRequest)
 		return
@@ -77,30 +88,147 @@ func (handler *UserHandler) DoLogin(w http.ResponseWriter, r *http.Request, _ ht
 		return
 	}
 
-	user, created, err := handler.Service.DoLogin(request.Username)
+	user, err := handler.Service.UpdateUsername(auid, request.Username)
 	if err != nil {
 		errors.WriteHTTPError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(user); err != nil {
+		return
+	}
+}
+
+type SetMyPasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"omitempty,max=72"`
+	NewPassword     string `json:"newPassword" validate:"required,min=8,max=72"`
+}
+
+func (handler *UserHandler) SetMyPassword(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sessionID, ok := middlewares.GetSessionIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	var request SetMyPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	err = handler.Service.UpdatePassword(auid, sid, clientAddress(r), request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (handler *UserHandler) SetMyPhoto(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := extendTransferDeadline(w, handler.TransferTimeout); err != nil {
+		errors.WriteHTTPError(w, errors.ErrInternal)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, utils.MaxImageSize+utils.MultipartOverhead)
+	if err := parseMultipartForm(r); err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	image, err := readImageUpload(r, "image")
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
 
-	if created {
-		w.WriteHeader(http.StatusCreated)
-	} else {
-		w.WriteHeader(http.StatusOK)
+	user, err := handler.Service.UpdateProfilePicture(auid, image)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
 	if err = json.NewEncoder(w).Encode(user); err != nil {
 		return
 	}
 }
 
-type SetMyUsernameRequest struct {
-	Username string `json:"username" validate:"required,min=3,max=16"`
+func (handler *UserHandler) GetMyBlocks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	users, err := handler.Service.GetBlockedUsers(auid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if err = json.NewEncoder(w).Encode(users); err != nil {
+		return
+	}
 }
 
-func (handler *UserHandler) SetMyUserName(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+type BlockUserRequest struct {
+	UserID uuid.UUID `json:"userId" validate:"required"`
+}
+
+func (handler *UserHandler) BlockUser(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
 	if !ok {
 		errors.WriteHTTPError(w, errors.ErrUnauthorized)
@@ -113,7 +241,7 @@ func (handler *UserHandler) SetMyUserName(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var request SetMyUsernameRequest
+	var request BlockUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		errors.WriteHTTPError(w, errors.ErrBadRequest)
 		return
@@ -125,21 +253,21 @@ func (handler *UserHandler) SetMyUserName(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := handler.Service.UpdateUsername(auid, request.Username)
+	user, err := handler.Service.BlockUser(auid, request.UserID)
 	if err != nil {
 		errors.WriteHTTPError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusCreated)
 
 	if err = json.NewEncoder(w).Encode(user); err != nil {
 		return
 	}
 }
 
-func (handler *UserHandler) SetMyPhoto(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+func (handler *UserHandler) UnblockUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
 	if !ok {
 		errors.WriteHTTPError(w, errors.ErrUnauthorized)
@@ -152,63 +280,89 @@ func (handler *UserHandler) SetMyPhoto(w http.ResponseWriter, r *http.Request, _
 		return
 	}
 
-	var dstFilename, dstPath string
+	userID := ps.ByName("userId")
 
-	if err := r.ParseMultipartForm(5 << 20); err != nil {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
 		errors.WriteHTTPError(w, errors.ErrBadRequest)
 		return
 	}
 
-	file, header, err := r.FormFile("image")
-	if err == nil && file != nil {
-		defer file.Close()
+	err = handler.Service.UnblockUser(auid, uid)
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
+	}
 
-		ext := strings.ToLower(filepath.Ext(header.Filename))
-		if ext != utils.ExtJPG && ext != utils.ExtJPEG && ext != utils.ExtPNG && ext != utils.ExtWEBP {
-			errors.WriteHTTPError(w, errors.ErrBadRequest)
-			return
-		}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-		dstFilename = uuid.New().String() + ext
-		dstPath = "./tmp/uploads/profile-pictures/" + dstFilename
+func (handler *UserHandler) GetMyPrivacy(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
 	}
 
-	profilePicture := ""
-	if dstFilename != "" {
-		profilePicture = "/uploads/profile-pictures/" + dstFilename
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
 	}
 
-	user, err := handler.Service.UpdateProfilePicture(auid, profilePicture)
+	settings, err := handler.Service.GetPrivacySettings(auid)
 	if err != nil {
 		errors.WriteHTTPError(w, err)
 		return
 	}
 
-	if file != nil && dstPath != "" {
-		dstDir := "./tmp/uploads/profile-pictures"
-		if err := os.MkdirAll(dstDir, 0755); err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 
-		dst, err := os.Create(dstPath)
-		if err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+	if err = json.NewEncoder(w).Encode(settings); err != nil {
+		return
+	}
+}
 
-		defer dst.Close()
+type SetMyPrivacyRequest struct {
+	GroupAdd string `json:"groupAdd" validate:"required,oneof=everyone contacts nobody"`
+}
 
-		if _, err := io.Copy(dst, file); err != nil {
-			errors.WriteHTTPError(w, errors.ErrInternal)
-			return
-		}
+func (handler *UserHandler) SetMyPrivacy(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	authenticatedUserID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	auid, err := uuid.Parse(authenticatedUserID)
+	if err != nil {
+		errors.WriteHTTPError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	var request SetMyPrivacyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		errors.WriteHTTPError(w, errors.ErrBadRequest)
+		return
+	}
+
+	settings, err := handler.Service.UpdatePrivacySettings(auid, models.PrivacySettings{GroupAdd: request.GroupAdd})
+	if err != nil {
+		errors.WriteHTTPError(w, err)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	if err = json.NewEncoder(w).Encode(user); err != nil {
+	if err = json.NewEncoder(w).Encode(settings); err != nil {
 		return
 	}
 }
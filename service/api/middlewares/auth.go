@@ -7,47 +7,55 @@ import (
 
 	"github.com/evaevangelisti/wasatext/service/api/repositories"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
-	"github.com/google/uuid"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	userIDKey    contextKey = "userID"
+	sessionIDKey contextKey = "sessionID"
+)
+
+func AuthMiddleware(sessionRepository *repositories.SessionRepository, next http.Handler) http.Handler {
+	return authenticate(sessionRepository, next, true)
+}
+
+func OptionalAuthMiddleware(sessionRepository *repositories.SessionRepository, next http.Handler) http.Handler {
+	return authenticate(sessionRepository, next, false)
+}
 
-func AuthMiddleware(userRepository *repositories.UserRepository, next http.Handler) http.Handler {
+func authenticate(sessionRepository *repositories.SessionRepository, next http.Handler, required bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			errors.WriteHTTPError(w, errors.ErrUnauthorized)
-			return
-		}
+			if required {
+				errors.WriteHTTPError(w, errors.ErrUnauthorized)
+				return
+			}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			errors.WriteHTTPError(w, errors.ErrUnauthorized)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		userID := parts[1]
-
-		uid, err := uuid.Parse(userID)
-		if err != nil {
+		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
 			errors.WriteHTTPError(w, errors.ErrUnauthorized)
 			return
 		}
 
-		user, err := userRepository.GetUserByID(uid)
+		session, err := sessionRepository.GetActiveSessionByToken(parts[1])
 		if err != nil {
 			errors.WriteHTTPError(w, err)
 			return
 		}
 
-		if user == nil {
+		if session == nil {
 			errors.WriteHTTPError(w, errors.ErrUnauthorized)
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		ctx := context.WithValue(r.Context(), userIDKey, session.User.ID.String())
+		ctx = context.WithValue(ctx, sessionIDKey, session.ID.String())
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
@@ -56,3 +64,8 @@ func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(userIDKey).(string)
 	return userID, ok
 }
+
+func GetSessionIDFromContext(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(sessionIDKey).(string)
+	return sessionID, ok
+}
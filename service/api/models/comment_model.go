@@ -9,6 +9,12 @@ import (
 type Comment struct {
 	ID          uuid.UUID `json:"commentId" validate:"required"`
 	Commenter   User      `json:"commenter" validate:"required"`
-	Emoji       string    `json:"emoji" validate:"required,min=1,max=16"`
+	Emoji       string    `json:"emoji" validate:"required,min=1,max=32"`
 	CommentedAt time.Time `json:"commentedAt" validate:"required"`
 }
+
+type Reaction struct {
+	Emoji       string `json:"emoji" validate:"required,min=1,max=32"`
+	Count       int    `json:"count" validate:"min=1"`
+	ReactedByMe bool   `json:"reactedByMe"`
+}
@@ -12,27 +12,78 @@ type Conversation interface {
 }
 
 type PrivateConversation struct {
-	ID           uuid.UUID `json:"conversationId" validate:"required"`
-	Type         string    `json:"type" validate:"required,oneof=private group"`
-	Participants []User    `json:"participants" validate:"required,min=2,max=2"`
-	LastMessage  *Message  `json:"lastMessage,omitempty" validate:"omitempty"`
-	Messages     []Message `json:"messages,omitempty" validate:"omitempty,max=1000"`
-	CreatedAt    time.Time `json:"createdAt" validate:"required"`
+	ID           uuid.UUID             `json:"conversationId" validate:"required"`
+	Type         string                `json:"type" validate:"required,oneof=private group"`
+	Participants []User                `json:"participants" validate:"required,min=2,max=2"`
+	LastMessage  *Message              `json:"lastMessage,omitempty" validate:"omitempty"`
+	Messages     []Message             `json:"messages,omitempty" validate:"omitempty,max=100"`
+	NextCursor   string                `json:"nextCursor,omitempty" validate:"omitempty"`
+	Pins         []PinnedMessage       `json:"pins,omitempty" validate:"omitempty,max=10"`
+	Settings     *ConversationSettings `json:"settings,omitempty" validate:"omitempty"`
+	UnreadCount  int                   `json:"unreadCount" validate:"min=0"`
+	CreatedAt    time.Time             `json:"createdAt" validate:"required"`
 }
 
 func (conversation *PrivateConversation) GetID() uuid.UUID { return conversation.ID }
 func (conversation *PrivateConversation) GetType() string  { return conversation.Type }
 
 type GroupConversation struct {
-	ID          uuid.UUID `json:"conversationId" validate:"required"`
-	Type        string    `json:"type" validate:"required,oneof=private group"`
-	Name        string    `json:"name" validate:"required,min=1,max=50"`
-	Photo       string    `json:"photo,omitempty" validate:"omitempty,url,min=11,max=255"`
-	Members     []User    `json:"members" validate:"required,min=1,max=100"`
-	LastMessage *Message  `json:"lastMessage,omitempty" validate:"omitempty"`
-	Messages    []Message `json:"messages,omitempty" validate:"omitempty,max=1000"`
-	CreatedAt   time.Time `json:"createdAt" validate:"required"`
+	ID             uuid.UUID             `json:"conversationId" validate:"required"`
+	Type           string                `json:"type" validate:"required,oneof=private group"`
+	Name           string                `json:"name" validate:"required,min=1,max=50"`
+	Photo          string                `json:"photo,omitempty" validate:"omitempty,url,min=11,max=255"`
+	PhotoThumbnail string                `json:"photoThumbnail,omitempty" validate:"omitempty,url,min=11,max=255"`
+	Members        []Member              `json:"members" validate:"required,min=1,max=100"`
+	Permissions    GroupPermissions      `json:"permissions" validate:"required"`
+	LastMessage    *Message              `json:"lastMessage,omitempty" validate:"omitempty"`
+	Messages       []Message             `json:"messages,omitempty" validate:"omitempty,max=100"`
+	NextCursor     string                `json:"nextCursor,omitempty" validate:"omitempty"`
+	Pins           []PinnedMessage       `json:"pins,omitempty" validate:"omitempty,max=10"`
+	Settings       *ConversationSettings `json:"settings,omitempty" validate:"omitempty"`
+	UnreadCount    int                   `json:"unreadCount" validate:"min=0"`
+	CreatedAt      time.Time             `json:"createdAt" validate:"required"`
 }
 
 func (conversation *GroupConversation) GetID() uuid.UUID { return conversation.ID }
 func (conversation *GroupConversation) GetType() string  { return conversation.Type }
+
+type Member struct {
+	User
+	Role string `json:"role" validate:"required,oneof=owner admin member"`
+}
+
+type GroupPermissions struct {
+	Rename      string `json:"rename" validate:"required,oneof=members admins"`
+	ChangePhoto string `json:"changePhoto" validate:"required,oneof=members admins"`
+	AddMembers  string `json:"addMembers" validate:"required,oneof=members admins"`
+	Post        string `json:"post" validate:"required,oneof=members admins"`
+	Pin         string `json:"pin" validate:"required,oneof=members admins"`
+}
+
+type PinnedMessage struct {
+	Message  Message   `json:"message" validate:"required"`
+	PinnedBy User      `json:"pinnedBy" validate:"required"`
+	PinnedAt time.Time `json:"pinnedAt" validate:"required"`
+}
+
+type ConversationSettings struct {
+	MutedUntil time.Time `json:"mutedUntil" validate:"omitempty"`
+	Archived   bool      `json:"archived"`
+	Pinned     bool      `json:"pinned"`
+}
+
+type ConversationSettingsUpdate struct {
+	MutedUntil *time.Time
+	Archived   *bool
+	Pinned     *bool
+}
+
+type GroupInvite struct {
+	ID        uuid.UUID `json:"inviteId" validate:"required"`
+	Token     string    `json:"token,omitempty" validate:"omitempty,min=43,max=43"`
+	CreatedBy User      `json:"createdBy" validate:"required"`
+	MaxUses   int       `json:"maxUses,omitempty" validate:"omitempty,min=1,max=100"`
+	Uses      int       `json:"uses" validate:"min=0"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	ExpiresAt time.Time `json:"expiresAt" validate:"required"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Credential struct {
+	UserID       uuid.UUID `json:"-"`
+	PasswordHash string    `json:"-"`
+	UpdatedAt    time.Time `json:"-"`
+}
+
+type LoginAttempt struct {
+	UserID         uuid.UUID  `json:"-"`
+	Client         string     `json:"-"`
+	FailedAttempts int        `json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+	UpdatedAt      time.Time  `json:"-"`
+}
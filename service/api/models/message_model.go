@@ -7,17 +7,99 @@ import (
 )
 
 type Message struct {
-	ID                uuid.UUID `json:"messageId" validate:"required"`
-	Sender            User      `json:"sender" validate:"required"`
-	Content           string    `json:"content,omitempty" validate:"omitempty,min=1,max=1000"`
-	Attachment        string    `json:"attachment,omitempty" validate:"omitempty,url,min=11,max=255"`
-	Comments          []Comment `json:"comments,omitempty" validate:"omitempty,max=100"`
-	IsForwarded       bool      `json:"isForwarded" validate:"required"`
-	OriginalMessageID uuid.UUID `json:"originalMessageId,omitempty" validate:"omitempty"`
-	ReplyToMessageID  uuid.UUID `json:"replyToMessageId,omitempty" validate:"omitempty"`
-	Trackings         struct {
-		Read map[uuid.UUID]time.Time `json:"read,omitempty" validate:"omitempty"`
-	} `json:"trackings,omitempty" validate:"omitempty"`
-	SentAt   time.Time `json:"sentAt" validate:"required"`
-	EditedAt time.Time `json:"editedAt,omitempty" validate:"omitempty"`
+	ID                uuid.UUID        `json:"messageId" validate:"required"`
+	Type              string           `json:"type" validate:"required,oneof=text system"`
+	Sender            User             `json:"sender" validate:"required"`
+	Event             string           `json:"event,omitempty" validate:"omitempty,oneof=member_added member_joined member_left member_removed group_renamed photo_changed"`
+	Actor             *User            `json:"actor,omitempty" validate:"omitempty"`
+	Target            *User            `json:"target,omitempty" validate:"omitempty"`
+	Content           string           `json:"content,omitempty" validate:"omitempty,min=1,max=1000"`
+	Attachments       []Attachment     `json:"attachments,omitempty" validate:"omitempty,max=10,dive"`
+	Comments          []Comment        `json:"comments,omitempty" validate:"omitempty,max=100"`
+	Reactions         []Reaction       `json:"reactions,omitempty" validate:"omitempty,dive"`
+	IsForwarded       bool             `json:"isForwarded" validate:"required"`
+	OriginalMessageID uuid.UUID        `json:"originalMessageId,omitempty" validate:"omitempty"`
+	ForwardedFrom     *ForwardedFrom   `json:"forwardedFrom,omitempty" validate:"omitempty"`
+	ReplyToMessageID  uuid.UUID        `json:"replyToMessageId,omitempty" validate:"omitempty"`
+	ReplyTo           *MessagePreview  `json:"replyTo,omitempty" validate:"omitempty"`
+	Trackings         MessageTrackings `json:"trackings,omitempty" validate:"omitempty"`
+	SentAt            time.Time        `json:"sentAt" validate:"required"`
+	EditedAt          time.Time        `json:"editedAt,omitempty" validate:"omitempty"`
+	DeletedAt         time.Time        `json:"deletedAt,omitempty" validate:"omitempty"`
+}
+
+type Attachment struct {
+	URL       string  `json:"url" validate:"required,url,min=11,max=255"`
+	Thumbnail string  `json:"thumbnail,omitempty" validate:"omitempty,url,min=11,max=255"`
+	Type      string  `json:"type" validate:"required,oneof=image audio video document"`
+	MimeType  string  `json:"mimeType" validate:"required,min=1,max=255"`
+	Filename  string  `json:"filename,omitempty" validate:"omitempty,min=1,max=255"`
+	Size      int64   `json:"size" validate:"min=0"`
+	Duration  float64 `json:"duration,omitempty" validate:"omitempty,min=0"`
+}
+
+type ForwardedFrom struct {
+	Sender User      `json:"sender" validate:"required"`
+	SentAt time.Time `json:"sentAt" validate:"required"`
+}
+
+type ScheduledMessage struct {
+	ID               uuid.UUID  `json:"scheduledMessageId" validate:"required"`
+	ConversationID   uuid.UUID  `json:"conversationId" validate:"required"`
+	SenderID         uuid.UUID  `json:"senderId" validate:"required"`
+	Content          string     `json:"content" validate:"required,min=1,max=1000"`
+	ReplyToMessageID uuid.UUID  `json:"replyToMessageId,omitempty" validate:"omitempty"`
+	CreatedAt        time.Time  `json:"createdAt" validate:"required"`
+	SendAt           time.Time  `json:"sendAt" validate:"required"`
+	Status           string     `json:"status" validate:"required,oneof=pending sending failed"`
+	FailedAt         *time.Time `json:"failedAt,omitempty" validate:"omitempty"`
+	FailureReason    string     `json:"failureReason,omitempty" validate:"omitempty,max=100"`
+}
+
+type MessagePreview struct {
+	ID             uuid.UUID `json:"messageId" validate:"required"`
+	Sender         User      `json:"sender" validate:"required"`
+	Snippet        string    `json:"snippet,omitempty" validate:"omitempty,max=1000"`
+	AttachmentType string    `json:"attachmentType,omitempty" validate:"omitempty,oneof=image audio video document"`
+	Thumbnail      string    `json:"thumbnail,omitempty" validate:"omitempty,url,min=11,max=255"`
+	IsDeleted      bool      `json:"isDeleted"`
+}
+
+type MessageRevision struct {
+	ID         uuid.UUID `json:"revisionId" validate:"required"`
+	Content    string    `json:"content,omitempty" validate:"omitempty,min=1,max=1000"`
+	CreatedAt  time.Time `json:"createdAt" validate:"required"`
+	ReplacedAt time.Time `json:"replacedAt" validate:"required"`
+}
+
+type MessageTrackings struct {
+	Delivered map[uuid.UUID]time.Time `json:"delivered,omitempty" validate:"omitempty"`
+	Read      map[uuid.UUID]time.Time `json:"read,omitempty" validate:"omitempty"`
+}
+
+type MessagePage struct {
+	Messages   []Message `json:"messages" validate:"required,max=100"`
+	NextCursor string    `json:"nextCursor,omitempty" validate:"omitempty"`
+}
+
+type MessageSearchResult struct {
+	ConversationID uuid.UUID `json:"conversationId" validate:"required"`
+	Message        Message   `json:"message" validate:"required"`
+	Snippet        string    `json:"snippet" validate:"required"`
+}
+
+type StarredMessage struct {
+	ConversationID uuid.UUID `json:"conversationId" validate:"required"`
+	Message        Message   `json:"message" validate:"required"`
+	StarredAt      time.Time `json:"starredAt" validate:"required"`
+}
+
+type StarredMessagePage struct {
+	Starred    []StarredMessage `json:"starred" validate:"required,max=100"`
+	NextCursor string           `json:"nextCursor,omitempty" validate:"omitempty"`
+}
+
+type MessageSearchPage struct {
+	Results    []MessageSearchResult `json:"results" validate:"required,max=100"`
+	NextCursor string                `json:"nextCursor,omitempty" validate:"omitempty"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Session struct {
+	ID        uuid.UUID `json:"sessionId" validate:"required"`
+	Token     string    `json:"token,omitempty" validate:"omitempty,min=43,max=43"`
+	User      User      `json:"user" validate:"required"`
+	IsCurrent bool      `json:"isCurrent"`
+	CreatedAt time.Time `json:"createdAt" validate:"required"`
+	ExpiresAt time.Time `json:"expiresAt" validate:"required"`
+}
@@ -7,8 +7,13 @@ import (
 )
 
 type User struct {
-	ID             uuid.UUID `json:"userId" validate:"required"`
-	Username       string    `json:"username" validate:"required,min=3,max=16"`
-	ProfilePicture string    `json:"profilePicture,omitempty" validate:"omitempty,url,min=11,max=255"`
-	CreatedAt      time.Time `json:"createdAt" validate:"required"`
+	ID                      uuid.UUID `json:"userId" validate:"required"`
+	Username                string    `json:"username" validate:"required,min=3,max=16"`
+	ProfilePicture          string    `json:"profilePicture,omitempty" validate:"omitempty,url,min=11,max=255"`
+	ProfilePictureThumbnail string    `json:"profilePictureThumbnail,omitempty" validate:"omitempty,url,min=11,max=255"`
+	CreatedAt               time.Time `json:"createdAt" validate:"required"`
+}
+
+type PrivacySettings struct {
+	GroupAdd string `json:"groupAdd" validate:"required,oneof=everyone contacts nobody"`
 }
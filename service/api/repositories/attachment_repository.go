@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/google/uuid"
+)
+
+type AttachmentRepository struct {
+	Database database.Database
+}
+
+func (repository *AttachmentRepository) GetAttachmentsByMessageID(messageID uuid.UUID) ([]models.Attachment, error) {
+	attachments, err := repository.GetAttachmentsByMessageIDs([]uuid.UUID{messageID})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments[messageID], nil
+}
+
+func (repository *AttachmentRepository) GetAttachmentsByMessageIDs(messageIDs []uuid.UUID) (map[uuid.UUID][]models.Attachment, error) {
+	attachments := map[uuid.UUID][]models.Attachment{}
+
+	if len(messageIDs) == 0 {
+		return attachments, nil
+	}
+
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, len(messageIDs))
+	for i, mid := range messageIDs {
+		placeholders[i] = "?"
+		args[i] = mid.String()
+	}
+
+	rows, err := repository.Database.Query(
+		`SELECT message_id, url, thumbnail, type, mime_type, filename, size, duration
+		 FROM message_attachments
+		 WHERE message_id IN (`+strings.Join(placeholders, ",")+`)
+		 ORDER BY message_id, position ASC`, args...)
+
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			messageID           string
+			attachment          models.Attachment
+			thumbnail, filename sql.NullString
+			duration            sql.NullFloat64
+		)
+
+		if err := rows.Scan(&messageID, &attachment.URL, &thumbnail, &attachment.Type, &attachment.MimeType, &filename, &attachment.Size, &duration); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		mid, err := uuid.Parse(messageID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		attachment.Thumbnail = thumbnail.String
+		attachment.Filename = filename.String
+		attachment.Duration = duration.Float64
+
+		attachments[mid] = append(attachments[mid], attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return attachments, nil
+}
+
+func (repository *AttachmentRepository) GetConversationIDsByURL(url string) ([]uuid.UUID, error) {
+	rows, err := repository.Database.Query(
+		`SELECT DISTINCT m.conversation_id
+		 FROM message_attachments a
+		 JOIN messages m ON m.message_id = a.message_id
+		 WHERE a.url = ? OR a.thumbnail = ?`, url, url)
+
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	conversationIDs := []uuid.UUID{}
+
+	for rows.Next() {
+		var conversationID string
+
+		if err := rows.Scan(&conversationID); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		cid, err := uuid.Parse(conversationID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		conversationIDs = append(conversationIDs, cid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return conversationIDs, nil
+}
+
+func (repository *AttachmentRepository) GetUploadsByConversationID(conversationID uuid.UUID) ([]string, error) {
+	return repository.queryUploads(
+		`SELECT a.url, a.thumbnail
+		 FROM message_attachments a
+		 JOIN messages m ON m.message_id = a.message_id
+		 WHERE m.conversation_id = ?`, conversationID.String())
+}
+
+func (repository *AttachmentRepository) GetUploadsByMessageID(messageID uuid.UUID) ([]string, error) {
+	return repository.queryUploads("SELECT url, thumbnail FROM message_attachments WHERE message_id = ?", messageID.String())
+}
+
+func (repository *AttachmentRepository) queryUploads(query string, args ...interface{}) ([]string, error) {
+	rows, err := repository.Database.Query(query, args...)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	uploads := []string{}
+
+	for rows.Next() {
+		var (
+			url       string
+			thumbnail sql.NullString
+		)
+
+		if err := rows.Scan(&url, &thumbnail); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		uploads = append(uploads, url)
+
+		if thumbnail.Valid {
+			uploads = append(uploads, thumbnail.String)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return uploads, nil
+}
+
+func unreferencedUploads(tx *sql.Tx, uploads []string) ([]string, error) {
+	unreferenced := []string{}
+
+	for _, upload := range uploads {
+		var referenced bool
+
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM message_attachments WHERE url = ? OR thumbnail = ?)", upload, upload).Scan(&referenced)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		if !referenced {
+			unreferenced = append(unreferenced, upload)
+		}
+	}
+
+	return unreferenced, nil
+}
+
+func insertAttachments(tx *sql.Tx, messageID uuid.UUID, attachments []models.Attachment) error {
+	for position, attachment := range attachments {
+		_, err := tx.Exec(
+			`INSERT INTO message_attachments (message_id, position, url, thumbnail, type, mime_type, filename, size, duration)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			messageID.String(),
+			position,
+			attachment.URL,
+			sql.NullString{String: attachment.Thumbnail, Valid: attachment.Thumbnail != ""},
+			attachment.Type,
+			attachment.MimeType,
+			sql.NullString{String: attachment.Filename, Valid: attachment.Filename != ""},
+			attachment.Size,
+			sql.NullFloat64{Float64: attachment.Duration, Valid: attachment.Duration > 0},
+		)
+		if err != nil {
+			return errors.ErrInternal
+		}
+	}
+
+	return nil
+}
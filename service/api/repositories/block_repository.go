@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type BlockRepository struct {
+	Database database.Database
+}
+
+func (repository *BlockRepository) GetBlockedUsers(userID uuid.UUID) ([]models.User, error) {
+	rows, err := repository.Database.Query("SELECT blocked_id FROM blocks WHERE blocker_id = ? ORDER BY blocked_at DESC, rowid DESC", userID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	blockedIDs := []uuid.UUID{}
+
+	for rows.Next() {
+		var blockedID string
+
+		if err := rows.Scan(&blockedID); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		bid, err := uuid.Parse(blockedID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		blockedIDs = append(blockedIDs, bid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	userRepository := UserRepository{Database: repository.Database}
+
+	users := make([]models.User, 0, len(blockedIDs))
+
+	for _, blockedID := range blockedIDs {
+		user, err := userRepository.GetUserByID(blockedID)
+		if err != nil {
+			return nil, err
+		}
+
+		if user == nil {
+			continue
+		}
+
+		users = append(users, *user)
+	}
+
+	return users, nil
+}
+
+func (repository *BlockRepository) IsUserBlocked(blockerID, blockedID uuid.UUID) (bool, error) {
+	var blocked bool
+
+	err := repository.Database.QueryRow("SELECT EXISTS (SELECT 1 FROM blocks WHERE blocker_id = ? AND blocked_id = ?)", blockerID.String(), blockedID.String()).Scan(&blocked)
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return blocked, nil
+}
+
+func (repository *BlockRepository) IsEitherUserBlocked(userID, otherUserID uuid.UUID) (bool, error) {
+	var blocked bool
+
+	err := repository.Database.QueryRow("SELECT EXISTS (SELECT 1 FROM blocks WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))", userID.String(), otherUserID.String(), otherUserID.String(), userID.String()).Scan(&blocked)
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return blocked, nil
+}
+
+func (repository *BlockRepository) BlockUser(blockerID, blockedID uuid.UUID) error {
+	_, err := repository.Database.Exec("INSERT INTO blocks (blocker_id, blocked_id, blocked_at) VALUES (?, ?, ?)", blockerID.String(), blockedID.String(), globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *BlockRepository) UnblockUser(blockerID, blockedID uuid.UUID) error {
+	_, err := repository.Database.Exec("DELETE FROM blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID.String(), blockedID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
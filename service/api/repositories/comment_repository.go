@@ -89,6 +89,27 @@ func (repository *CommentRepository) GetCommentByID(commentID uuid.UUID) (*model
 	return &comment, nil
 }
 
+func (repository *CommentRepository) GetMessageIDByCommentID(commentID uuid.UUID) (uuid.UUID, error) {
+	row := repository.Database.QueryRow("SELECT message_id FROM comments WHERE comment_id = ?", commentID.String())
+
+	var messageID string
+
+	if err := row.Scan(&messageID); err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, errors.ErrNotFound
+		}
+
+		return uuid.Nil, errors.ErrInternal
+	}
+
+	mid, err := uuid.Parse(messageID)
+	if err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
+	return mid, nil
+}
+
 func (repository *CommentRepository) CreateComment(messageID, userID uuid.UUID, emoji string) (uuid.UUID, error) {
 	commentID := uuid.New()
 	commentedAt := globaltime.Now()
@@ -101,6 +122,15 @@ func (repository *CommentRepository) CreateComment(messageID, userID uuid.UUID,
 	return commentID, nil
 }
 
+func (repository *CommentRepository) UpdateComment(commentID uuid.UUID, emoji string) error {
+	_, err := repository.Database.Exec("UPDATE comments SET emoji = ?, commented_at = ? WHERE comment_id = ?", emoji, globaltime.Format(globaltime.Now()), commentID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
 func (repository *CommentRepository) DeleteComment(commentID uuid.UUID) error {
 	_, err := repository.Database.Exec("DELETE FROM comments WHERE comment_id = ?", commentID.String())
 	if err != nil {
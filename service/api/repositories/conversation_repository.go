@@ -3,10 +3,10 @@ package repositories
 import (
 	"database/sql"
 	stdErrors "errors"
-	"os"
 
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
 	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
 	"github.com/google/uuid"
@@ -16,29 +16,44 @@ type ConversationRepository struct {
 	Database database.Database
 }
 
-func (repository *ConversationRepository) GetConversationsByUserID(userID uuid.UUID) ([]models.Conversation, error) {
+func (repository *ConversationRepository) GetConversationsByUserID(userID uuid.UUID, archived *bool) ([]models.Conversation, error) {
 	query := `
 		SELECT c.conversation_id, (
         	SELECT m.message_id
          	FROM messages m
           	WHERE m.conversation_id = c.conversation_id
+          	AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = m.message_id AND h.user_id = ?)
            	ORDER BY m.sent_at DESC
             LIMIT 1
-        ) AS last_message_id
+        ) AS last_message_id, s.muted_until, s.archived, s.pinned
 		FROM conversations c
 		LEFT JOIN participants p ON c.conversation_id = p.conversation_id
 		LEFT JOIN members mbr ON c.conversation_id = mbr.conversation_id
-		WHERE p.user_id = ? OR mbr.user_id = ?
-		ORDER BY COALESCE((
+		LEFT JOIN conversation_settings s ON c.conversation_id = s.conversation_id AND s.user_id = ?
+		WHERE (p.user_id = ? OR mbr.user_id = ?)
+	`
+
+	queryArgs := []interface{}{userID.String(), userID.String(), userID.String(), userID.String()}
+
+	if archived != nil {
+		query += " AND COALESCE(s.archived, 0) = ?"
+		queryArgs = append(queryArgs, *archived)
+	}
+
+	query += `
+		ORDER BY COALESCE(s.pinned, 0) DESC, COALESCE((
         	SELECT m.sent_at
          	FROM messages m
           	WHERE m.conversation_id = c.conversation_id
+          	AND NOT EXISTS (SELECT 1 FROM hidden_messages h WHERE h.message_id = m.message_id AND h.user_id = ?)
            	ORDER BY m.sent_at DESC
             LIMIT 1
         ), c.created_at) DESC
 	`
 
-	rows, err := repository.Database.Query(query, userID.String(), userID.String())
+	queryArgs = append(queryArgs, userID.String())
+
+	rows, err := repository.Database.Query(query, queryArgs...)
 	if err != nil {
 		return nil, errors.ErrInternal
 	}
@@ -49,11 +64,13 @@ func (repository *ConversationRepository) GetConversationsByUserID(userID uuid.U
 
 	for rows.Next() {
 		var (
-			conversationID string
-			lastMessageID  sql.NullString
+			conversationID     string
+			lastMessageID      sql.NullString
+			mutedUntil         sql.NullString
+			isArchived, pinned sql.NullBool
 		)
 
-		if err := rows.Scan(&conversationID, &lastMessageID); err != nil {
+		if err := rows.Scan(&conversationID, &lastMessageID, &mutedUntil, &isArchived, &pinned); err != nil {
 			return nil, errors.ErrInternal
 		}
 
@@ -83,16 +100,23 @@ func (repository *ConversationRepository) GetConversationsByUserID(userID uuid.U
 			}
 		}
 
+		settings, err := parseConversationSettings(mutedUntil, isArchived, pinned)
+		if err != nil {
+			return nil, err
+		}
+
 		switch conversation := conversation.(type) {
 		case *models.PrivateConversation:
 			conversation.Messages = nil
 			conversation.LastMessage = lastMessage
+			conversation.Settings = settings
 
 			conversations = append(conversations, conversation)
 
 		case *models.GroupConversation:
 			conversation.Messages = nil
 			conversation.LastMessage = lastMessage
+			conversation.Settings = settings
 
 			conversations = append(conversations, conversation)
 		}
@@ -105,6 +129,60 @@ func (repository *ConversationRepository) GetConversationsByUserID(userID uuid.U
 	return conversations, nil
 }
 
+func parseConversationSettings(mutedUntil sql.NullString, archived, pinned sql.NullBool) (*models.ConversationSettings, error) {
+	settings := models.ConversationSettings{Archived: archived.Bool, Pinned: pinned.Bool}
+
+	if mutedUntil.Valid {
+		mutedUntilTime, err := globaltime.Parse(mutedUntil.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		settings.MutedUntil = mutedUntilTime
+	}
+
+	return &settings, nil
+}
+
+func (repository *ConversationRepository) GetConversationSettings(conversationID, userID uuid.UUID) (*models.ConversationSettings, error) {
+	row := repository.Database.QueryRow("SELECT muted_until, archived, pinned FROM conversation_settings WHERE conversation_id = ? AND user_id = ?", conversationID.String(), userID.String())
+
+	var (
+		mutedUntil       sql.NullString
+		archived, pinned sql.NullBool
+	)
+
+	if err := row.Scan(&mutedUntil, &archived, &pinned); err != nil && err != sql.ErrNoRows {
+		return nil, errors.ErrInternal
+	}
+
+	return parseConversationSettings(mutedUntil, archived, pinned)
+}
+
+func (repository *ConversationRepository) CountPinnedConversations(userID uuid.UUID) (int, error) {
+	var count int
+
+	if err := repository.Database.QueryRow("SELECT COUNT(*) FROM conversation_settings WHERE user_id = ? AND pinned = 1", userID.String()).Scan(&count); err != nil {
+		return 0, errors.ErrInternal
+	}
+
+	return count, nil
+}
+
+func (repository *ConversationRepository) UpdateConversationSettings(conversationID, userID uuid.UUID, settings models.ConversationSettings) error {
+	var mutedUntil sql.NullString
+	if !settings.MutedUntil.IsZero() {
+		mutedUntil = sql.NullString{String: globaltime.Format(settings.MutedUntil), Valid: true}
+	}
+
+	_, err := repository.Database.Exec("INSERT INTO conversation_settings (user_id, conversation_id, muted_until, archived, pinned, updated_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (user_id, conversation_id) DO UPDATE SET muted_until = excluded.muted_until, archived = excluded.archived, pinned = excluded.pinned, updated_at = excluded.updated_at", userID.String(), conversationID.String(), mutedUntil, settings.Archived, settings.Pinned, globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
 func (repository *ConversationRepository) GetConversationByID(conversationID uuid.UUID) (models.Conversation, error) {
 	row := repository.Database.QueryRow("SELECT type, created_at FROM conversations WHERE conversation_id = ?", conversationID.String())
 
@@ -123,13 +201,6 @@ func (repository *ConversationRepository) GetConversationByID(conversationID uui
 		return nil, errors.ErrInternal
 	}
 
-	messageRepository := MessageRepository{Database: repository.Database}
-
-	messages, err := messageRepository.GetMessagesByConversationID(conversationID)
-	if err != nil {
-		return nil, err
-	}
-
 	switch typ {
 	case "private":
 		participants, err := repository.GetParticipants(conversationID)
@@ -141,19 +212,19 @@ func (repository *ConversationRepository) GetConversationByID(conversationID uui
 			ID:           conversationID,
 			Type:         "private",
 			Participants: participants,
-			Messages:     messages,
 			CreatedAt:    createdAtTime,
 		}, nil
 
 	case "group":
-		row := repository.Database.QueryRow("SELECT name, photo FROM group_conversations WHERE conversation_id = ?", conversationID.String())
+		row := repository.Database.QueryRow("SELECT name, photo, photo_thumbnail, rename_permission, photo_permission, add_members_permission, post_permission, pin_permission FROM group_conversations WHERE conversation_id = ?", conversationID.String())
 
 		var (
-			name  string
-			photo sql.NullString
+			name                  string
+			photo, photoThumbnail sql.NullString
+			permissions           models.GroupPermissions
 		)
 
-		if err := row.Scan(&name, &photo); err != nil {
+		if err := row.Scan(&name, &photo, &photoThumbnail, &permissions.Rename, &permissions.ChangePhoto, &permissions.AddMembers, &permissions.Post, &permissions.Pin); err != nil {
 			if err == sql.ErrNoRows {
 				return nil, nil
 			}
@@ -167,18 +238,22 @@ func (repository *ConversationRepository) GetConversationByID(conversationID uui
 		}
 
 		groupConversation := &models.GroupConversation{
-			ID:        conversationID,
-			Type:      "group",
-			Name:      name,
-			Members:   members,
-			Messages:  messages,
-			CreatedAt: createdAtTime,
+			ID:          conversationID,
+			Type:        "group",
+			Name:        name,
+			Members:     members,
+			Permissions: permissions,
+			CreatedAt:   createdAtTime,
 		}
 
 		if photo.Valid {
 			groupConversation.Photo = photo.String
 		}
 
+		if photoThumbnail.Valid {
+			groupConversation.PhotoThumbnail = photoThumbnail.String
+		}
+
 		return groupConversation, nil
 
 	default:
@@ -270,7 +345,7 @@ func (repository *ConversationRepository) GetConversationByCommentID(commentID u
 }
 
 func (repository *ConversationRepository) GetParticipants(conversationID uuid.UUID) ([]models.User, error) {
-	rows, err := repository.Database.Query("SELECT u.user_id, u.username, u.profile_picture, u.created_at FROM participants p JOIN users u ON p.user_id = u.user_id WHERE p.conversation_id = ?", conversationID.String())
+	rows, err := repository.Database.Query("SELECT u.user_id, u.username, u.profile_picture, u.profile_picture_thumbnail, u.created_at FROM participants p JOIN users u ON p.user_id = u.user_id WHERE p.conversation_id = ?", conversationID.String())
 	if err != nil {
 		return nil, errors.ErrInternal
 	}
@@ -283,11 +358,11 @@ func (repository *ConversationRepository) GetParticipants(conversationID uuid.UU
 		var participant models.User
 
 		var (
-			participantID, createdAt string
-			profilePicture           sql.NullString
+			participantID, createdAt                string
+			profilePicture, profilePictureThumbnail sql.NullString
 		)
 
-		if err := rows.Scan(&participantID, &participant.Username, &profilePicture, &createdAt); err != nil {
+		if err := rows.Scan(&participantID, &participant.Username, &profilePicture, &profilePictureThumbnail, &createdAt); err != nil {
 			return nil, errors.ErrInternal
 		}
 
@@ -302,6 +377,10 @@ func (repository *ConversationRepository) GetParticipants(conversationID uuid.UU
 			participant.ProfilePicture = profilePicture.String
 		}
 
+		if profilePictureThumbnail.Valid {
+			participant.ProfilePictureThumbnail = profilePictureThumbnail.String
+		}
+
 		participant.CreatedAt, err = globaltime.Parse(createdAt)
 		if err != nil {
 			return nil, errors.ErrInternal
@@ -317,25 +396,25 @@ func (repository *ConversationRepository) GetParticipants(conversationID uuid.UU
 	return participants, nil
 }
 
-func (repository *ConversationRepository) GetMembers(conversationID uuid.UUID) ([]models.User, error) {
-	rows, err := repository.Database.Query("SELECT u.user_id, u.username, u.profile_picture, u.created_at FROM members m JOIN users u ON m.user_id = u.user_id WHERE m.conversation_id = ?", conversationID.String())
+func (repository *ConversationRepository) GetMembers(conversationID uuid.UUID) ([]models.Member, error) {
+	rows, err := repository.Database.Query("SELECT u.user_id, u.username, u.profile_picture, u.profile_picture_thumbnail, u.created_at, m.role FROM members m JOIN users u ON m.user_id = u.user_id WHERE m.conversation_id = ? ORDER BY m.rowid", conversationID.String())
 	if err != nil {
 		return nil, errors.ErrInternal
 	}
 
 	defer rows.Close()
 
-	var members []models.User
+	var members []models.Member
 
 	for rows.Next() {
-		var member models.User
+		var member models.Member
 
 		var (
-			memberID, createdAt string
-			profilePicture      sql.NullString
+			memberID, createdAt                     string
+			profilePicture, profilePictureThumbnail sql.NullString
 		)
 
-		if err := rows.Scan(&memberID, &member.Username, &profilePicture, &createdAt); err != nil {
+		if err := rows.Scan(&memberID, &member.Username, &profilePicture, &profilePictureThumbnail, &createdAt, &member.Role); err != nil {
 			return nil, errors.ErrInternal
 		}
 
@@ -350,6 +429,10 @@ func (repository *ConversationRepository) GetMembers(conversationID uuid.UUID) (
 			member.ProfilePicture = profilePicture.String
 		}
 
+		if profilePictureThumbnail.Valid {
+			member.ProfilePictureThumbnail = profilePictureThumbnail.String
+		}
+
 		member.CreatedAt, err = globaltime.Parse(createdAt)
 		if err != nil {
 			return nil, errors.ErrInternal
@@ -365,6 +448,57 @@ func (repository *ConversationRepository) GetMembers(conversationID uuid.UUID) (
 	return members, nil
 }
 
+func (repository *ConversationRepository) GetMemberRole(conversationID, userID uuid.UUID) (string, error) {
+	var role string
+
+	err := repository.Database.QueryRow("SELECT role FROM members WHERE conversation_id = ? AND user_id = ?", conversationID.String(), userID.String()).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+
+		return "", errors.ErrInternal
+	}
+
+	return role, nil
+}
+
+func (repository *ConversationRepository) GetUserIDsByConversationID(conversationID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := repository.Database.Query("SELECT user_id FROM participants WHERE conversation_id = ? UNION SELECT user_id FROM members WHERE conversation_id = ?", conversationID.String(), conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	userIDs := []uuid.UUID{}
+
+	for rows.Next() {
+		var userID sql.NullString
+
+		if err := rows.Scan(&userID); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		if !userID.Valid {
+			continue
+		}
+
+		uid, err := uuid.Parse(userID.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		userIDs = append(userIDs, uid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return userIDs, nil
+}
+
 func (repository *ConversationRepository) IsUserInConversation(conversationID, userID uuid.UUID) (bool, error) {
 	query := `
         SELECT 1
@@ -427,7 +561,7 @@ func (repository *ConversationRepository) CreatePrivateConversation(participantI
 	return conversationID, nil
 }
 
-func (repository *ConversationRepository) CreateGroupConversation(name string, memberIDs []uuid.UUID) (uuid.UUID, error) {
+func (repository *ConversationRepository) CreateGroupConversation(name string, ownerID uuid.UUID, memberIDs []uuid.UUID) (uuid.UUID, error) {
 	conversationID := uuid.New()
 	createdAt := globaltime.Now()
 
@@ -450,8 +584,17 @@ func (repository *ConversationRepository) CreateGroupConversation(name string, m
 		return uuid.Nil, errors.ErrInternal
 	}
 
+	_, err = tx.Exec("INSERT INTO members (conversation_id, user_id, role) VALUES (?, ?, ?)", conversationID.String(), ownerID.String(), utils.RoleOwner)
+	if err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
 	for _, userID := range memberIDs {
-		_, err = tx.Exec("INSERT INTO members (conversation_id, user_id) VALUES (?, ?)", conversationID.String(), userID.String())
+		if userID == ownerID {
+			continue
+		}
+
+		_, err = tx.Exec("INSERT OR IGNORE INTO members (conversation_id, user_id, role) VALUES (?, ?, ?)", conversationID.String(), userID.String(), utils.RoleMember)
 		if err != nil {
 			return uuid.Nil, errors.ErrInternal
 		}
@@ -473,8 +616,8 @@ func (repository *ConversationRepository) AddMember(conversationID, userID uuid.
 	return userID, nil
 }
 
-func (repository *ConversationRepository) UpdateGroupName(conversationID uuid.UUID, name string) error {
-	_, err := repository.Database.Exec("UPDATE group_conversations SET name = ? WHERE conversation_id = ?", name, conversationID.String())
+func (repository *ConversationRepository) UpdateMemberRole(conversationID, userID uuid.UUID, role string) error {
+	_, err := repository.Database.Exec("UPDATE members SET role = ? WHERE conversation_id = ? AND user_id = ?", role, conversationID.String(), userID.String())
 	if err != nil {
 		return errors.ErrInternal
 	}
@@ -482,83 +625,186 @@ func (repository *ConversationRepository) UpdateGroupName(conversationID uuid.UU
 	return nil
 }
 
-func (repository *ConversationRepository) UpdateGroupPhoto(conversationID uuid.UUID, photo string) error {
-	var oldPhoto sql.NullString
+func (repository *ConversationRepository) TransferOwnership(conversationID, ownerID, userID uuid.UUID) error {
+	tx, err := repository.Database.Begin()
+	if err != nil {
+		return errors.ErrInternal
+	}
 
-	err := repository.Database.QueryRow("SELECT photo FROM group_conversations WHERE conversation_id = ?", conversationID.String()).Scan(&oldPhoto)
-	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("UPDATE members SET role = ? WHERE conversation_id = ? AND user_id = ?", utils.RoleAdmin, conversationID.String(), ownerID.String())
+	if err != nil {
 		return errors.ErrInternal
 	}
 
-	_, err = repository.Database.Exec("UPDATE group_conversations SET photo = ? WHERE conversation_id = ?", sql.NullString{String: photo, Valid: photo != ""}, conversationID.String())
+	_, err = tx.Exec("UPDATE members SET role = ? WHERE conversation_id = ? AND user_id = ?", utils.RoleOwner, conversationID.String(), userID.String())
 	if err != nil {
 		return errors.ErrInternal
 	}
 
-	if oldPhoto.Valid && oldPhoto.String != "" {
-		if err := os.Remove("." + oldPhoto.String); err != nil && !os.IsNotExist(err) {
-			return errors.ErrInternal
-		}
+	if err := tx.Commit(); err != nil {
+		return errors.ErrInternal
 	}
 
 	return nil
 }
 
-func (repository *ConversationRepository) DeleteGroupConversation(conversationID uuid.UUID) error {
-	var groupPhoto sql.NullString
+func (repository *ConversationRepository) UpdateGroupPermissions(conversationID uuid.UUID, permissions models.GroupPermissions) error {
+	_, err := repository.Database.Exec("UPDATE group_conversations SET rename_permission = ?, photo_permission = ?, add_members_permission = ?, post_permission = ?, pin_permission = ? WHERE conversation_id = ?", permissions.Rename, permissions.ChangePhoto, permissions.AddMembers, permissions.Post, permissions.Pin, conversationID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
 
-	err := repository.Database.QueryRow("SELECT photo FROM group_conversations WHERE conversation_id = ?", conversationID.String()).Scan(&groupPhoto)
-	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
+	return nil
+}
+
+func (repository *ConversationRepository) UpdateGroupName(conversationID uuid.UUID, name string) error {
+	_, err := repository.Database.Exec("UPDATE group_conversations SET name = ? WHERE conversation_id = ?", name, conversationID.String())
+	if err != nil {
 		return errors.ErrInternal
 	}
 
+	return nil
+}
+
+func (repository *ConversationRepository) UpdateGroupPhoto(conversationID uuid.UUID, photo, photoThumbnail string) ([]string, error) {
+	var oldPhoto, oldPhotoThumbnail sql.NullString
+
+	err := repository.Database.QueryRow("SELECT photo, photo_thumbnail FROM group_conversations WHERE conversation_id = ?", conversationID.String()).Scan(&oldPhoto, &oldPhotoThumbnail)
+	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = repository.Database.Exec("UPDATE group_conversations SET photo = ?, photo_thumbnail = ? WHERE conversation_id = ?", sql.NullString{String: photo, Valid: photo != ""}, sql.NullString{String: photoThumbnail, Valid: photoThumbnail != ""}, conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return []string{oldPhoto.String, oldPhotoThumbnail.String}, nil
+}
+
+func (repository *ConversationRepository) DeleteGroupConversation(conversationID uuid.UUID) ([]string, error) {
+	var groupPhoto, groupPhotoThumbnail sql.NullString
+
+	err := repository.Database.QueryRow("SELECT photo, photo_thumbnail FROM group_conversations WHERE conversation_id = ?", conversationID.String()).Scan(&groupPhoto, &groupPhotoThumbnail)
+	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, errors.ErrInternal
+	}
+
+	attachmentRepository := AttachmentRepository{Database: repository.Database}
+
+	attachments, err := attachmentRepository.GetUploadsByConversationID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := repository.Database.Begin()
 	if err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
 	defer func() {
 		_ = tx.Rollback()
 	}()
 
+	_, err = tx.Exec("DELETE FROM message_attachments WHERE message_id IN (SELECT message_id FROM messages WHERE conversation_id = ?)", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	attachments, err = unreferencedUploads(tx, attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("DELETE FROM message_revisions WHERE message_id IN (SELECT message_id FROM messages WHERE conversation_id = ?)", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM hidden_messages WHERE message_id IN (SELECT message_id FROM messages WHERE conversation_id = ?)", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM starred_messages WHERE message_id IN (SELECT message_id FROM messages WHERE conversation_id = ?)", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM pinned_messages WHERE conversation_id = ?", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM conversation_settings WHERE conversation_id = ?", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM group_invites WHERE conversation_id = ?", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM scheduled_messages WHERE conversation_id = ?", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
 	_, err = tx.Exec("DELETE FROM messages WHERE conversation_id = ?", conversationID.String())
 	if err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
 	_, err = tx.Exec("DELETE FROM group_conversations WHERE conversation_id = ?", conversationID.String())
 	if err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
 	_, err = tx.Exec("DELETE FROM members WHERE conversation_id = ?", conversationID.String())
 	if err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
 	_, err = tx.Exec("DELETE FROM conversations WHERE conversation_id = ?", conversationID.String())
 	if err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
 	if err := tx.Commit(); err != nil {
-		return errors.ErrInternal
-	}
-
-	if groupPhoto.Valid && groupPhoto.String != "" {
-		if err := os.Remove("." + groupPhoto.String); err != nil && !os.IsNotExist(err) {
-			return errors.ErrInternal
-		}
+		return nil, errors.ErrInternal
 	}
 
-	return nil
+	return append([]string{groupPhoto.String, groupPhotoThumbnail.String}, attachments...), nil
 }
 
 func (repository *ConversationRepository) RemoveMember(conversationID, userID uuid.UUID) error {
-	_, err := repository.Database.Exec("DELETE FROM members WHERE conversation_id = ? AND user_id = ?", conversationID.String(), userID.String())
+	tx, err := repository.Database.Begin()
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("DELETE FROM members WHERE conversation_id = ? AND user_id = ?", conversationID.String(), userID.String())
 	if err != nil {
 		return errors.ErrInternal
 	}
 
+	_, err = tx.Exec("DELETE FROM conversation_settings WHERE conversation_id = ? AND user_id = ?", conversationID.String(), userID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	if err = tx.Commit(); err != nil {
+		return errors.ErrInternal
+	}
+
 	return nil
 }
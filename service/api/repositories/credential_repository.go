@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type CredentialRepository struct {
+	Database database.Database
+}
+
+func (repository *CredentialRepository) GetCredentialByUserID(userID uuid.UUID) (*models.Credential, error) {
+	row := repository.Database.QueryRow("SELECT password_hash, updated_at FROM user_credentials WHERE user_id = ?", userID.String())
+
+	credential := models.Credential{UserID: userID}
+
+	var updatedAt string
+
+	if err := row.Scan(&credential.PasswordHash, &updatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, errors.ErrInternal
+	}
+
+	var err error
+
+	credential.UpdatedAt, err = globaltime.Parse(updatedAt)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return &credential, nil
+}
+
+func (repository *CredentialRepository) SetPasswordHash(userID uuid.UUID, passwordHash string) error {
+	_, err := repository.Database.Exec("INSERT INTO user_credentials (user_id, password_hash, updated_at) VALUES (?, ?, ?) ON CONFLICT (user_id) DO UPDATE SET password_hash = excluded.password_hash, updated_at = excluded.updated_at", userID.String(), passwordHash, globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *CredentialRepository) RecordLoginAttempt(userID uuid.UUID, client string, now time.Time, maxAttempts int, lockoutDuration time.Duration) (*models.LoginAttempt, error) {
+	now = now.UTC()
+
+	row := repository.Database.QueryRow(`INSERT INTO login_attempts (user_id, client, failed_attempts, locked_until, updated_at) VALUES (?, ?, 1, NULL, ?)
+		 ON CONFLICT (user_id, client) DO UPDATE SET
+			failed_attempts = CASE
+				WHEN locked_until IS NOT NULL AND locked_until <= excluded.updated_at THEN 1
+				WHEN locked_until IS NOT NULL THEN failed_attempts
+				ELSE failed_attempts + 1
+			END,
+			locked_until = CASE
+				WHEN locked_until IS NOT NULL AND locked_until <= excluded.updated_at THEN NULL
+				WHEN locked_until IS NULL AND failed_attempts + 1 > ? THEN ?
+				ELSE locked_until
+			END,
+			updated_at = excluded.updated_at
+		 RETURNING failed_attempts, locked_until, updated_at`, userID.String(), client, globaltime.Format(now), maxAttempts, globaltime.Format(now.Add(lockoutDuration)))
+
+	attempt := models.LoginAttempt{UserID: userID, Client: client}
+
+	var (
+		updatedAt   string
+		lockedUntil sql.NullString
+	)
+
+	if err := row.Scan(&attempt.FailedAttempts, &lockedUntil, &updatedAt); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	if lockedUntil.Valid {
+		parsedLockedUntil, err := globaltime.Parse(lockedUntil.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		attempt.LockedUntil = &parsedLockedUntil
+	}
+
+	var err error
+
+	attempt.UpdatedAt, err = globaltime.Parse(updatedAt)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return &attempt, nil
+}
+
+func (repository *CredentialRepository) ResetFailedAttempts(userID uuid.UUID, client string) error {
+	_, err := repository.Database.Exec("DELETE FROM login_attempts WHERE user_id = ? AND client = ?", userID.String(), client)
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type InviteRepository struct {
+	Database database.Database
+}
+
+const activeInviteCondition = "revoked_at IS NULL AND expires_at > ? AND (max_uses IS NULL OR uses < max_uses)"
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (repository *InviteRepository) GetActiveInvites(conversationID uuid.UUID) ([]models.GroupInvite, error) {
+	rows, err := repository.Database.Query("SELECT invite_id, created_by, max_uses, uses, created_at, expires_at FROM group_invites WHERE conversation_id = ? AND "+activeInviteCondition+" ORDER BY created_at DESC, rowid DESC", conversationID.String(), globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	type rawInvite struct {
+		ID, CreatedAt, ExpiresAt string
+		CreatedBy                sql.NullString
+		MaxUses                  sql.NullInt64
+		Uses                     int
+	}
+
+	rawInvites := []rawInvite{}
+
+	for rows.Next() {
+		var ri rawInvite
+
+		if err := rows.Scan(&ri.ID, &ri.CreatedBy, &ri.MaxUses, &ri.Uses, &ri.CreatedAt, &ri.ExpiresAt); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		rawInvites = append(rawInvites, ri)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	invites := make([]models.GroupInvite, 0, len(rawInvites))
+
+	for _, ri := range rawInvites {
+		invite, err := repository.buildInvite(ri.ID, ri.CreatedBy, ri.MaxUses, ri.Uses, ri.CreatedAt, ri.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		invites = append(invites, *invite)
+	}
+
+	return invites, nil
+}
+
+func (repository *InviteRepository) GetActiveInviteByID(conversationID, inviteID uuid.UUID) (*models.GroupInvite, error) {
+	row := repository.Database.QueryRow("SELECT invite_id, created_by, max_uses, uses, created_at, expires_at FROM group_invites WHERE conversation_id = ? AND invite_id = ? AND "+activeInviteCondition, conversationID.String(), inviteID.String(), globaltime.Format(globaltime.Now()))
+
+	var (
+		id, createdAt, expiresAt string
+		createdBy                sql.NullString
+		maxUses                  sql.NullInt64
+		uses                     int
+	)
+
+	if err := row.Scan(&id, &createdBy, &maxUses, &uses, &createdAt, &expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, errors.ErrInternal
+	}
+
+	return repository.buildInvite(id, createdBy, maxUses, uses, createdAt, expiresAt)
+}
+
+func (repository *InviteRepository) CreateInvite(conversationID, createdBy uuid.UUID, token string, maxUses int, expiresAt time.Time) (uuid.UUID, error) {
+	inviteID := uuid.New()
+
+	var maxUsesValue sql.NullInt64
+	if maxUses > 0 {
+		maxUsesValue = sql.NullInt64{Int64: int64(maxUses), Valid: true}
+	}
+
+	_, err := repository.Database.Exec("INSERT INTO group_invites (invite_id, token_hash, conversation_id, created_by, max_uses, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)", inviteID.String(), hashInviteToken(token), conversationID.String(), createdBy.String(), maxUsesValue, globaltime.Format(globaltime.Now()), globaltime.Format(expiresAt))
+	if err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
+	return inviteID, nil
+}
+
+func (repository *InviteRepository) RevokeInvite(inviteID uuid.UUID) error {
+	_, err := repository.Database.Exec("UPDATE group_invites SET revoked_at = ? WHERE invite_id = ? AND revoked_at IS NULL", globaltime.Format(globaltime.Now()), inviteID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *InviteRepository) UseInvite(token string) (uuid.UUID, uuid.UUID, error) {
+	row := repository.Database.QueryRow("SELECT invite_id, conversation_id FROM group_invites WHERE token_hash = ? AND "+activeInviteCondition, hashInviteToken(token), globaltime.Format(globaltime.Now()))
+
+	var inviteID, conversationID string
+
+	if err := row.Scan(&inviteID, &conversationID); err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, uuid.Nil, errors.ErrNotFound
+		}
+
+		return uuid.Nil, uuid.Nil, errors.ErrInternal
+	}
+
+	result, err := repository.Database.Exec("UPDATE group_invites SET uses = uses + 1 WHERE invite_id = ? AND "+activeInviteCondition, inviteID, globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.ErrInternal
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.ErrInternal
+	}
+
+	if affected == 0 {
+		return uuid.Nil, uuid.Nil, errors.ErrNotFound
+	}
+
+	iid, err := uuid.Parse(inviteID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.ErrInternal
+	}
+
+	cid, err := uuid.Parse(conversationID)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, errors.ErrInternal
+	}
+
+	return iid, cid, nil
+}
+
+func (repository *InviteRepository) ReleaseInvite(inviteID uuid.UUID) error {
+	_, err := repository.Database.Exec("UPDATE group_invites SET uses = uses - 1 WHERE invite_id = ? AND uses > 0", inviteID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *InviteRepository) buildInvite(inviteID string, createdBy sql.NullString, maxUses sql.NullInt64, uses int, createdAt, expiresAt string) (*models.GroupInvite, error) {
+	var invite models.GroupInvite
+
+	var err error
+
+	invite.ID, err = uuid.Parse(inviteID)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	if createdBy.Valid {
+		cbid, err := uuid.Parse(createdBy.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		userRepository := UserRepository{Database: repository.Database}
+
+		user, err := userRepository.GetUserByID(cbid)
+		if err != nil {
+			return nil, err
+		}
+
+		if user != nil {
+			invite.CreatedBy = *user
+		}
+	}
+
+	if maxUses.Valid {
+		invite.MaxUses = int(maxUses.Int64)
+	}
+
+	invite.Uses = uses
+
+	invite.CreatedAt, err = globaltime.Parse(createdAt)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	invite.ExpiresAt, err = globaltime.Parse(expiresAt)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return &invite, nil
+}
@@ -2,13 +2,16 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/base64"
 	stdErrors "errors"
-	"os"
+	"html"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
 	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
 	"github.com/google/uuid"
@@ -18,81 +21,208 @@ type MessageRepository struct {
 	Database database.Database
 }
 
-func (repository *MessageRepository) GetMessagesByConversationID(conversationID uuid.UUID) ([]models.Message, error) {
-	rows, err := repository.Database.Query(
-		`SELECT message_id, sender_id, content, attachment, sent_at, edited_at, reply_to_message_id
+func encodeMessageCursor(sentAt string, rowID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sentAt + "|" + strconv.FormatInt(rowID, 10)))
+}
+
+func decodeMessageCursor(cursor string) (string, int64, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, errors.ErrBadRequest
+	}
+
+	parts := strings.SplitN(string(decoded), "|", 2)
+	if len(parts) != 2 {
+		return "", 0, errors.ErrBadRequest
+	}
+
+	if _, err := globaltime.Parse(parts[0]); err != nil {
+		return "", 0, errors.ErrBadRequest
+	}
+
+	rowID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, errors.ErrBadRequest
+	}
+
+	return parts[0], rowID, nil
+}
+
+func newMessageTrackings() *models.MessageTrackings {
+	return &models.MessageTrackings{
+		Delivered: map[uuid.UUID]time.Time{},
+		Read:      map[uuid.UUID]time.Time{},
+	}
+}
+
+func setSystemEvent(message *models.Message, event string, target *models.User) {
+	actor := message.Sender
+
+	message.Event = event
+	message.Actor = &actor
+	message.Target = target
+}
+
+func appendTracking(trackings *models.MessageTrackings, userID uuid.UUID, deliveredAt, readAt string) error {
+	if deliveredAt != "" {
+		deliveredAtTime, err := globaltime.Parse(deliveredAt)
+		if err != nil {
+			return errors.ErrInternal
+		}
+
+		trackings.Delivered[userID] = deliveredAtTime
+	}
+
+	if readAt != "" {
+		readAtTime, err := globaltime.Parse(readAt)
+		if err != nil {
+			return errors.ErrInternal
+		}
+
+		trackings.Read[userID] = readAtTime
+	}
+
+	return nil
+}
+
+func (repository *MessageRepository) GetMessagesByConversationID(conversationID, userID uuid.UUID, before string, limit int) ([]models.Message, string, error) {
+	return repository.listMessages("conversation_id = ?", []interface{}{conversationID.String()}, userID, before, limit)
+}
+
+func (repository *MessageRepository) GetRepliesByMessageID(messageID, userID uuid.UUID, before string, limit int) ([]models.Message, string, error) {
+	return repository.listMessages("reply_to_message_id = ?", []interface{}{messageID.String()}, userID, before, limit)
+}
+
+func (repository *MessageRepository) listMessages(filter string, filterArgs []interface{}, userID uuid.UUID, before string, limit int) ([]models.Message, string, error) {
+	query := `SELECT rowid, message_id, type, event, target_id, sender_id, content, sent_at, edited_at, deleted_at, reply_to_message_id
 		 FROM messages
-		 WHERE conversation_id = ?
-		 ORDER BY sent_at ASC`, conversationID.String())
+		 WHERE ` + filter + `
+		 AND NOT EXISTS (
+			SELECT 1 FROM hidden_messages h
+			WHERE h.message_id = messages.message_id AND h.user_id = ?
+		 )`
 
+	queryArgs := append(filterArgs, userID.String())
+
+	if before != "" {
+		beforeSentAt, beforeRowID, err := decodeMessageCursor(before)
+		if err != nil {
+			return nil, "", err
+		}
+
+		query += " AND (sent_at < ? OR (sent_at = ? AND rowid < ?))"
+		queryArgs = append(queryArgs, beforeSentAt, beforeSentAt, beforeRowID)
+	}
+
+	query += " ORDER BY sent_at DESC, rowid DESC LIMIT ?"
+	queryArgs = append(queryArgs, limit+1)
+
+	rows, err := repository.Database.Query(query, queryArgs...)
 	if err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	defer rows.Close()
 
 	type rawMessage struct {
+		RowID            int64
 		ID               uuid.UUID
+		Type             string
+		Event            string
+		TargetID         uuid.UUID
 		SenderID         uuid.UUID
 		Content          string
-		Attachment       string
 		SentAt           string
 		EditedAt         string
+		DeletedAt        string
 		ReplyToMessageID uuid.UUID
 	}
 
 	rawMessages := []rawMessage{}
 	messageIDs := []uuid.UUID{}
+	replyToMessageIDs := []uuid.UUID{}
 	senderIDs := map[uuid.UUID]struct{}{}
 
 	for rows.Next() {
 		var (
-			messageID, senderID, sentAt                     string
-			content, attachment, editedAt, replyToMessageID sql.NullString
+			rowID                                    int64
+			messageID, messageType, senderID, sentAt string
+			event, targetID                          sql.NullString
+			content, editedAt, replyToMessageID      sql.NullString
+			deletedAt                                sql.NullString
 		)
 
-		if err := rows.Scan(&messageID, &senderID, &content, &attachment, &sentAt, &editedAt, &replyToMessageID); err != nil {
-			return nil, errors.ErrInternal
+		if err := rows.Scan(&rowID, &messageID, &messageType, &event, &targetID, &senderID, &content, &sentAt, &editedAt, &deletedAt, &replyToMessageID); err != nil {
+			return nil, "", errors.ErrInternal
 		}
 
 		mid, err := uuid.Parse(messageID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		sid, err := uuid.Parse(senderID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		var rtmid uuid.UUID
 		if replyToMessageID.Valid && replyToMessageID.String != "" {
 			rtmid, err = uuid.Parse(replyToMessageID.String)
 			if err != nil {
-				return nil, errors.ErrInternal
+				return nil, "", errors.ErrInternal
+			}
+		}
+
+		var tid uuid.UUID
+		if targetID.Valid {
+			tid, err = uuid.Parse(targetID.String)
+			if err != nil {
+				return nil, "", errors.ErrInternal
 			}
+
+			senderIDs[tid] = struct{}{}
 		}
 
 		rawMessages = append(rawMessages, rawMessage{
+			RowID:            rowID,
 			ID:               mid,
+			Type:             messageType,
+			Event:            event.String,
+			TargetID:         tid,
 			SenderID:         sid,
 			Content:          content.String,
-			Attachment:       attachment.String,
 			SentAt:           sentAt,
 			EditedAt:         editedAt.String,
+			DeletedAt:        deletedAt.String,
 			ReplyToMessageID: rtmid,
 		})
 
 		messageIDs = append(messageIDs, mid)
 		senderIDs[sid] = struct{}{}
+
+		if rtmid != uuid.Nil {
+			replyToMessageIDs = append(replyToMessageIDs, rtmid)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
+	}
+
+	hasMore := len(rawMessages) > limit
+
+	if hasMore {
+		rawMessages = rawMessages[:limit]
+		messageIDs = messageIDs[:limit]
+	}
+
+	for i, j := 0, len(rawMessages)-1; i < j; i, j = i+1, j-1 {
+		rawMessages[i], rawMessages[j] = rawMessages[j], rawMessages[i]
 	}
 
 	if len(rawMessages) == 0 {
-		return []models.Message{}, nil
+		return []models.Message{}, "", nil
 	}
 
 	placeholders := make([]string, len(messageIDs))
@@ -109,7 +239,7 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 		 ORDER BY commented_at ASC`, args...)
 
 	if err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	defer commentRows.Close()
@@ -131,22 +261,22 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 		)
 
 		if err := commentRows.Scan(&commentID, &emoji, &commentedAt, &messageID, &userID); err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		cid, err := uuid.Parse(commentID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		mid, err := uuid.Parse(messageID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		uid, err := uuid.Parse(userID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		rawComments = append(rawComments, rawComment{
@@ -161,56 +291,62 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 	}
 
 	if err := commentRows.Err(); err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	trackingRows, err := repository.Database.Query(
-		`SELECT message_id, user_id, read_at
+		`SELECT message_id, user_id, delivered_at, read_at
 		 FROM message_trackings
 		 WHERE message_id IN (`+strings.Join(placeholders, ",")+`)`, args...)
 
 	if err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	defer trackingRows.Close()
 
 	type rawTracking struct {
-		MessageID uuid.UUID
-		UserID    uuid.UUID
-		ReadAt    string
+		MessageID   uuid.UUID
+		UserID      uuid.UUID
+		DeliveredAt string
+		ReadAt      string
 	}
 
 	rawTrackings := []rawTracking{}
 	trackingUserIDs := map[uuid.UUID]struct{}{}
 
 	for trackingRows.Next() {
-		var messageID, userID, readAt string
-		if err := trackingRows.Scan(&messageID, &userID, &readAt); err != nil {
-			return nil, errors.ErrInternal
+		var (
+			messageID, userID   string
+			deliveredAt, readAt sql.NullString
+		)
+
+		if err := trackingRows.Scan(&messageID, &userID, &deliveredAt, &readAt); err != nil {
+			return nil, "", errors.ErrInternal
 		}
 
 		mid, err := uuid.Parse(messageID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		uid, err := uuid.Parse(userID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		rawTrackings = append(rawTrackings, rawTracking{
-			MessageID: mid,
-			UserID:    uid,
-			ReadAt:    readAt,
+			MessageID:   mid,
+			UserID:      uid,
+			DeliveredAt: deliveredAt.String,
+			ReadAt:      readAt.String,
 		})
 
 		trackingUserIDs[uid] = struct{}{}
 	}
 
 	if err := trackingRows.Err(); err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	allUserIDs := map[uuid.UUID]struct{}{}
@@ -241,12 +377,12 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 	}
 
 	userRows, err := repository.Database.Query(
-		`SELECT user_id, username, profile_picture, created_at
+		`SELECT user_id, username, profile_picture, profile_picture_thumbnail, created_at
 		 FROM users
 		 WHERE user_id IN (`+strings.Join(userPlaceholders, ",")+`)`, userArgs...)
 
 	if err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	defer userRows.Close()
@@ -255,19 +391,19 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 
 	for userRows.Next() {
 		var userID, username, createdAt string
-		var profilePicture sql.NullString
-		if err := userRows.Scan(&userID, &username, &profilePicture, &createdAt); err != nil {
-			return nil, errors.ErrInternal
+		var profilePicture, profilePictureThumbnail sql.NullString
+		if err := userRows.Scan(&userID, &username, &profilePicture, &profilePictureThumbnail, &createdAt); err != nil {
+			return nil, "", errors.ErrInternal
 		}
 
 		uid, err := uuid.Parse(userID)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		createdAtTime, err := globaltime.Parse(createdAt)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		user := models.User{
@@ -281,11 +417,15 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 			user.ProfilePicture = profilePicture.String
 		}
 
+		if profilePictureThumbnail.Valid {
+			user.ProfilePictureThumbnail = profilePictureThumbnail.String
+		}
+
 		userMap[uid] = user
 	}
 
 	if err := userRows.Err(); err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	commentsByMessage := map[uuid.UUID][]models.Comment{}
@@ -293,7 +433,7 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 	for _, rc := range rawComments {
 		commentedAtTime, err := globaltime.Parse(rc.CommentedAt)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		comment := models.Comment{
@@ -306,56 +446,78 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 		commentsByMessage[rc.MessageID] = append(commentsByMessage[rc.MessageID], comment)
 	}
 
-	trackingByMessage := map[uuid.UUID]map[uuid.UUID]time.Time{}
+	trackingByMessage := map[uuid.UUID]*models.MessageTrackings{}
 
 	for _, rt := range rawTrackings {
-		readAtTime, err := globaltime.Parse(rt.ReadAt)
-		if err != nil {
-			return nil, errors.ErrInternal
+		trackings, ok := trackingByMessage[rt.MessageID]
+		if !ok {
+			trackings = newMessageTrackings()
+			trackingByMessage[rt.MessageID] = trackings
 		}
 
-		if trackingByMessage[rt.MessageID] == nil {
-			trackingByMessage[rt.MessageID] = make(map[uuid.UUID]time.Time)
+		if err := appendTracking(trackings, rt.UserID, rt.DeliveredAt, rt.ReadAt); err != nil {
+			return nil, "", err
 		}
+	}
+
+	attachmentRepository := AttachmentRepository{Database: repository.Database}
+
+	attachmentsByMessage, err := attachmentRepository.GetAttachmentsByMessageIDs(messageIDs)
+	if err != nil {
+		return nil, "", err
+	}
 
-		trackingByMessage[rt.MessageID][rt.UserID] = readAtTime
+	previews, err := repository.GetMessagePreviewsByIDs(replyToMessageIDs)
+	if err != nil {
+		return nil, "", err
 	}
 
 	forwardRows, err := repository.Database.Query(
-		`SELECT forwarded_message_id, original_message_id
-		 FROM forwarded_messages
-		 WHERE forwarded_message_id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+		`SELECT f.forwarded_message_id, f.original_message_id, f.original_sent_at,
+			u.user_id, u.username, u.profile_picture, u.profile_picture_thumbnail, u.created_at
+		 FROM forwarded_messages f
+		 LEFT JOIN users u ON u.user_id = f.original_sender_id
+		 WHERE f.forwarded_message_id IN (`+strings.Join(placeholders, ",")+`)`, args...)
 
 	if err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	defer forwardRows.Close()
 
 	forwardedMap := map[uuid.UUID]uuid.UUID{}
+	forwardedFromMap := map[uuid.UUID]*models.ForwardedFrom{}
 
 	for forwardRows.Next() {
-		var fmid, omid string
+		var (
+			fmid, omid string
+			origin     forwardOrigin
+		)
 
-		if err := forwardRows.Scan(&fmid, &omid); err != nil {
-			return nil, errors.ErrInternal
+		if err := forwardRows.Scan(&fmid, &omid, &origin.SentAt, &origin.UserID, &origin.Username, &origin.ProfilePicture, &origin.ProfilePictureThumbnail, &origin.CreatedAt); err != nil {
+			return nil, "", errors.ErrInternal
 		}
 
 		fmidUUID, err := uuid.Parse(fmid)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		omidUUID, err := uuid.Parse(omid)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		forwardedMap[fmidUUID] = omidUUID
+
+		forwardedFromMap[fmidUUID], err = origin.parse()
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	if err := forwardRows.Err(); err != nil {
-		return nil, errors.ErrInternal
+		return nil, "", errors.ErrInternal
 	}
 
 	messages := make([]models.Message, 0, len(rawMessages))
@@ -363,60 +525,320 @@ func (repository *MessageRepository) GetMessagesByConversationID(conversationID
 	for _, rm := range rawMessages {
 		sentAtTime, err := globaltime.Parse(rm.SentAt)
 		if err != nil {
-			return nil, errors.ErrInternal
+			return nil, "", errors.ErrInternal
 		}
 
 		var editedAtTime time.Time
 		if rm.EditedAt != "" {
 			editedAtTime, err = globaltime.Parse(rm.EditedAt)
 			if err != nil {
-				return nil, errors.ErrInternal
+				return nil, "", errors.ErrInternal
+			}
+		}
+
+		var deletedAtTime time.Time
+		if rm.DeletedAt != "" {
+			deletedAtTime, err = globaltime.Parse(rm.DeletedAt)
+			if err != nil {
+				return nil, "", errors.ErrInternal
 			}
 		}
 
 		msg := models.Message{
 			ID:               rm.ID,
+			Type:             rm.Type,
 			Sender:           userMap[rm.SenderID],
 			Content:          rm.Content,
-			Attachment:       rm.Attachment,
+			Attachments:      attachmentsByMessage[rm.ID],
 			Comments:         commentsByMessage[rm.ID],
 			IsForwarded:      false,
 			ReplyToMessageID: rm.ReplyToMessageID,
-			Trackings: struct {
-				Read map[uuid.UUID]time.Time `json:"read,omitempty" validate:"omitempty"`
-			}{
-				Read: trackingByMessage[rm.ID],
-			},
-			SentAt:   sentAtTime,
-			EditedAt: editedAtTime,
+			Trackings:        *newMessageTrackings(),
+			SentAt:           sentAtTime,
+			EditedAt:         editedAtTime,
+			DeletedAt:        deletedAtTime,
+		}
+
+		if trackings, ok := trackingByMessage[rm.ID]; ok {
+			msg.Trackings = *trackings
+		}
+
+		if rm.Type == utils.MessageTypeSystem {
+			var target *models.User
+			if user, ok := userMap[rm.TargetID]; ok {
+				target = &user
+			}
+
+			setSystemEvent(&msg, rm.Event, target)
 		}
 
 		if omid, ok := forwardedMap[rm.ID]; ok {
 			msg.IsForwarded = true
 			msg.OriginalMessageID = omid
+			msg.ForwardedFrom = forwardedFromMap[rm.ID]
 		}
 
-		if msg.Trackings.Read == nil {
-			msg.Trackings.Read = make(map[uuid.UUID]time.Time)
+		if preview, ok := previews[rm.ReplyToMessageID]; ok {
+			msg.ReplyTo = &preview
 		}
 
 		messages = append(messages, msg)
 	}
 
-	return messages, nil
+	nextCursor := ""
+
+	if hasMore {
+		nextCursor = encodeMessageCursor(rawMessages[0].SentAt, rawMessages[0].RowID)
+	}
+
+	return messages, nextCursor, nil
+}
+
+const (
+	snippetMatchStart = "\ue000"
+	snippetMatchEnd   = "\ue001"
+
+	snippetWords        = 16
+	snippetContextWords = 4
+)
+
+func buildSearchQuery(q string) string {
+	terms := strings.Fields(q)
+	if len(terms) == 0 {
+		return ""
+	}
+
+	for i, term := range terms {
+		terms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func highlightSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	snippet = strings.ReplaceAll(snippet, snippetMatchStart, "<mark>")
+	return strings.ReplaceAll(snippet, snippetMatchEnd, "</mark>")
+}
+
+func escapeLikePattern(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+func buildSnippet(content string, terms []string) string {
+	words := strings.Fields(content)
+
+	matches := make([]bool, len(words))
+	first := -1
+
+	for i, word := range words {
+		lowerWord := strings.ToLower(word)
+
+		for _, term := range terms {
+			if strings.Contains(lowerWord, strings.ToLower(term)) {
+				matches[i] = true
+				break
+			}
+		}
+
+		if matches[i] && first < 0 {
+			first = i
+		}
+	}
+
+	start := first - snippetContextWords
+	if start < 0 {
+		start = 0
+	}
+
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	var snippet strings.Builder
+
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+
+	for i := start; i < end; i++ {
+		if i > start {
+			snippet.WriteString(" ")
+		}
+
+		if matches[i] {
+			snippet.WriteString(snippetMatchStart + words[i] + snippetMatchEnd)
+		} else {
+			snippet.WriteString(words[i])
+		}
+	}
+
+	if end < len(words) {
+		snippet.WriteString("…")
+	}
+
+	return snippet.String()
+}
+
+func (repository *MessageRepository) SearchMessages(userID uuid.UUID, q string, conversationID, senderID uuid.UUID, before string, limit int) ([]models.MessageSearchResult, string, error) {
+	match := buildSearchQuery(q)
+	if match == "" {
+		return nil, "", errors.ErrBadRequest
+	}
+
+	fullTextSearch := repository.Database.Supports(database.FeatureFTS5)
+
+	var (
+		query     string
+		queryArgs []interface{}
+	)
+
+	if fullTextSearch {
+		query = `SELECT m.rowid, m.message_id, m.conversation_id, m.sent_at, snippet(messages_fts, 0, ?, ?, '…', 16)
+		 FROM messages_fts
+		 JOIN messages m ON m.rowid = messages_fts.rowid
+		 WHERE messages_fts MATCH ?`
+
+		queryArgs = []interface{}{snippetMatchStart, snippetMatchEnd, match}
+	} else {
+		query = "SELECT m.rowid, m.message_id, m.conversation_id, m.sent_at, m.content FROM messages m WHERE 1 = 1"
+
+		for _, term := range strings.Fields(q) {
+			query += ` AND m.content LIKE ? ESCAPE '\'`
+			queryArgs = append(queryArgs, "%"+escapeLikePattern(term)+"%")
+		}
+	}
+
+	query += `
+		 AND m.type = 'text'
+		 AND m.conversation_id IN (
+			SELECT conversation_id FROM participants WHERE user_id = ?
+			UNION
+			SELECT conversation_id FROM members WHERE user_id = ?
+		 )
+		 AND NOT EXISTS (
+			SELECT 1 FROM hidden_messages h
+			WHERE h.message_id = m.message_id AND h.user_id = ?
+		 )`
+
+	queryArgs = append(queryArgs, userID.String(), userID.String(), userID.String())
+
+	if conversationID != uuid.Nil {
+		query += " AND m.conversation_id = ?"
+		queryArgs = append(queryArgs, conversationID.String())
+	}
+
+	if senderID != uuid.Nil {
+		query += " AND m.sender_id = ?"
+		queryArgs = append(queryArgs, senderID.String())
+	}
+
+	if before != "" {
+		beforeSentAt, beforeRowID, err := decodeMessageCursor(before)
+		if err != nil {
+			return nil, "", err
+		}
+
+		query += " AND (m.sent_at < ? OR (m.sent_at = ? AND m.rowid < ?))"
+		queryArgs = append(queryArgs, beforeSentAt, beforeSentAt, beforeRowID)
+	}
+
+	query += " ORDER BY m.sent_at DESC, m.rowid DESC LIMIT ?"
+	queryArgs = append(queryArgs, limit+1)
+
+	rows, err := repository.Database.Query(query, queryArgs...)
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	type rawResult struct {
+		RowID          int64
+		MessageID      uuid.UUID
+		ConversationID uuid.UUID
+		SentAt         string
+		Snippet        string
+	}
+
+	rawResults := []rawResult{}
+
+	for rows.Next() {
+		var (
+			rr                                       rawResult
+			messageID, resultConversationID, snippet string
+		)
+
+		if err := rows.Scan(&rr.RowID, &messageID, &resultConversationID, &rr.SentAt, &snippet); err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		rr.MessageID, err = uuid.Parse(messageID)
+		if err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		rr.ConversationID, err = uuid.Parse(resultConversationID)
+		if err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		if !fullTextSearch {
+			snippet = buildSnippet(snippet, strings.Fields(q))
+		}
+
+		rr.Snippet = highlightSnippet(snippet)
+
+		rawResults = append(rawResults, rr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	var nextCursor string
+	if len(rawResults) > limit {
+		rawResults = rawResults[:limit]
+		last := rawResults[len(rawResults)-1]
+		nextCursor = encodeMessageCursor(last.SentAt, last.RowID)
+	}
+
+	results := make([]models.MessageSearchResult, 0, len(rawResults))
+
+	for _, rr := range rawResults {
+		message, err := repository.GetMessageByID(rr.MessageID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if message == nil {
+			continue
+		}
+
+		results = append(results, models.MessageSearchResult{
+			ConversationID: rr.ConversationID,
+			Message:        *message,
+			Snippet:        rr.Snippet,
+		})
+	}
+
+	return results, nextCursor, nil
 }
 
 func (repository *MessageRepository) GetMessageByID(messageID uuid.UUID) (*models.Message, error) {
-	row := repository.Database.QueryRow("SELECT sender_id, content, attachment, sent_at, edited_at, reply_to_message_id FROM messages WHERE message_id = ?", messageID.String())
+	row := repository.Database.QueryRow("SELECT type, event, target_id, sender_id, content, sent_at, edited_at, deleted_at, reply_to_message_id FROM messages WHERE message_id = ?", messageID.String())
 
 	var message models.Message
 
 	var (
-		senderID, sentAt                                string
-		content, attachment, editedAt, replyToMessageID sql.NullString
+		messageType, senderID, sentAt       string
+		event, targetID                     sql.NullString
+		content, editedAt, replyToMessageID sql.NullString
+		deletedAt                           sql.NullString
 	)
 
-	if err := row.Scan(&senderID, &content, &attachment, &sentAt, &editedAt, &replyToMessageID); err != nil {
+	if err := row.Scan(&messageType, &event, &targetID, &senderID, &content, &sentAt, &editedAt, &deletedAt, &replyToMessageID); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -438,14 +860,36 @@ func (repository *MessageRepository) GetMessageByID(messageID uuid.UUID) (*model
 		return nil, err
 	}
 
+	message.Type = messageType
 	message.Sender = *sender
 
+	if messageType == utils.MessageTypeSystem {
+		var target *models.User
+
+		if targetID.Valid {
+			tid, err := uuid.Parse(targetID.String)
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+
+			target, err = userRepository.GetUserByID(tid)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		setSystemEvent(&message, event.String, target)
+	}
+
 	if content.Valid {
 		message.Content = content.String
 	}
 
-	if attachment.Valid {
-		message.Attachment = attachment.String
+	attachmentRepository := AttachmentRepository{Database: repository.Database}
+
+	message.Attachments, err = attachmentRepository.GetAttachmentsByMessageID(message.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	if replyToMessageID.Valid && replyToMessageID.String != "" {
@@ -453,6 +897,15 @@ func (repository *MessageRepository) GetMessageByID(messageID uuid.UUID) (*model
 		if err != nil {
 			return nil, errors.ErrInternal
 		}
+
+		previews, err := repository.GetMessagePreviewsByIDs([]uuid.UUID{message.ReplyToMessageID})
+		if err != nil {
+			return nil, err
+		}
+
+		if preview, ok := previews[message.ReplyToMessageID]; ok {
+			message.ReplyTo = &preview
+		}
 	}
 
 	commentRepository := CommentRepository{Database: repository.Database}
@@ -464,36 +917,52 @@ func (repository *MessageRepository) GetMessageByID(messageID uuid.UUID) (*model
 
 	message.Comments = comments
 
-	forwardRow := repository.Database.QueryRow(`SELECT original_message_id FROM forwarded_messages WHERE forwarded_message_id = ?`, message.ID.String())
+	forwardRow := repository.Database.QueryRow(
+		`SELECT f.original_message_id, f.original_sent_at,
+			u.user_id, u.username, u.profile_picture, u.profile_picture_thumbnail, u.created_at
+		 FROM forwarded_messages f
+		 LEFT JOIN users u ON u.user_id = f.original_sender_id
+		 WHERE f.forwarded_message_id = ?`, message.ID.String())
 
-	var originalMessageID string
+	var (
+		originalMessageID string
+		origin            forwardOrigin
+	)
 
-	if err := forwardRow.Scan(&originalMessageID); err == nil {
+	if err := forwardRow.Scan(&originalMessageID, &origin.SentAt, &origin.UserID, &origin.Username, &origin.ProfilePicture, &origin.ProfilePictureThumbnail, &origin.CreatedAt); err == nil {
 		message.IsForwarded = true
 
 		message.OriginalMessageID, err = uuid.Parse(originalMessageID)
 		if err != nil {
 			return nil, errors.ErrInternal
 		}
+
+		message.ForwardedFrom, err = origin.parse()
+		if err != nil {
+			return nil, err
+		}
 	} else if !stdErrors.Is(err, sql.ErrNoRows) {
 		return nil, errors.ErrInternal
 	} else {
 		message.IsForwarded = false
 	}
 
-	trackingRows, err := repository.Database.Query(`SELECT user_id, read_at FROM message_trackings WHERE message_id = ?`, message.ID.String())
+	trackingRows, err := repository.Database.Query(`SELECT user_id, delivered_at, read_at FROM message_trackings WHERE message_id = ?`, message.ID.String())
 	if err != nil {
 		return nil, errors.ErrInternal
 	}
 
 	defer trackingRows.Close()
 
-	message.Trackings.Read = make(map[uuid.UUID]time.Time)
+	trackings := newMessageTrackings()
 
 	for trackingRows.Next() {
-		var userID, readAtStr string
+		var (
+			userID              string
+			deliveredAt, readAt sql.NullString
+		)
 
-		if err := trackingRows.Scan(&userID, &readAtStr); err != nil {
+		if err := trackingRows.Scan(&userID, &deliveredAt, &readAt); err != nil {
 			return nil, errors.ErrInternal
 		}
 
@@ -502,16 +971,13 @@ func (repository *MessageRepository) GetMessageByID(messageID uuid.UUID) (*model
 			return nil, errors.ErrInternal
 		}
 
-		readAtTime, err := globaltime.Parse(readAtStr)
-		if err != nil {
-			return nil, errors.ErrInternal
-		}
-
-		if readAtStr != "" {
-			message.Trackings.Read[uid] = readAtTime
+		if err := appendTracking(trackings, uid, deliveredAt.String, readAt.String); err != nil {
+			return nil, err
 		}
 	}
 
+	message.Trackings = *trackings
+
 	if err := trackingRows.Err(); err != nil {
 		return nil, errors.ErrInternal
 	}
@@ -528,86 +994,470 @@ func (repository *MessageRepository) GetMessageByID(messageID uuid.UUID) (*model
 		}
 	}
 
+	if deletedAt.Valid && deletedAt.String != "" {
+		message.DeletedAt, err = globaltime.Parse(deletedAt.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+	}
+
 	return &message, nil
 }
 
-func (repository *MessageRepository) CreateMessage(conversationID, userID uuid.UUID, content, attachment string, replyToMessageID uuid.UUID) (uuid.UUID, error) {
+func truncateSnippet(content string) string {
+	runes := []rune(content)
+	if len(runes) <= utils.MessagePreviewSnippetLength {
+		return content
+	}
+
+	return strings.TrimSpace(string(runes[:utils.MessagePreviewSnippetLength])) + "…"
+}
+
+func (repository *MessageRepository) GetMessagePreviewsByIDs(messageIDs []uuid.UUID) (map[uuid.UUID]models.MessagePreview, error) {
+	previews := map[uuid.UUID]models.MessagePreview{}
+
+	if len(messageIDs) == 0 {
+		return previews, nil
+	}
+
+	placeholders := make([]string, len(messageIDs))
+	args := make([]interface{}, len(messageIDs))
+	for i, mid := range messageIDs {
+		placeholders[i] = "?"
+		args[i] = mid.String()
+	}
+
+	rows, err := repository.Database.Query(
+		`SELECT m.message_id, m.content, m.deleted_at, u.user_id, u.username, u.profile_picture, u.profile_picture_thumbnail, u.created_at
+		 FROM messages m
+		 JOIN users u ON u.user_id = m.sender_id
+		 WHERE m.message_id IN (`+strings.Join(placeholders, ",")+`)`, args...)
+
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			messageID, userID, username, createdAt  string
+			content, deletedAt                      sql.NullString
+			profilePicture, profilePictureThumbnail sql.NullString
+		)
+
+		if err := rows.Scan(&messageID, &content, &deletedAt, &userID, &username, &profilePicture, &profilePictureThumbnail, &createdAt); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		mid, err := uuid.Parse(messageID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		createdAtTime, err := globaltime.Parse(createdAt)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		previews[mid] = models.MessagePreview{
+			ID: mid,
+			Sender: models.User{
+				ID:                      uid,
+				Username:                username,
+				ProfilePicture:          profilePicture.String,
+				ProfilePictureThumbnail: profilePictureThumbnail.String,
+				CreatedAt:               createdAtTime,
+			},
+			Snippet:   truncateSnippet(content.String),
+			IsDeleted: deletedAt.Valid && deletedAt.String != "",
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	attachmentRepository := AttachmentRepository{Database: repository.Database}
+
+	attachmentsByMessage, err := attachmentRepository.GetAttachmentsByMessageIDs(messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for mid, attachments := range attachmentsByMessage {
+		preview, ok := previews[mid]
+		if !ok || len(attachments) == 0 {
+			continue
+		}
+
+		preview.AttachmentType = attachments[0].Type
+		preview.Thumbnail = attachments[0].Thumbnail
+		previews[mid] = preview
+	}
+
+	return previews, nil
+}
+
+func (repository *MessageRepository) CreateMessage(conversationID, userID uuid.UUID, content string, attachments []models.Attachment, replyToMessageID uuid.UUID) (uuid.UUID, error) {
 	messageID := uuid.New()
 	sentAt := globaltime.Now()
 
-	_, err := repository.Database.Exec("INSERT INTO messages (message_id, conversation_id, sender_id, content, attachment, sent_at, reply_to_message_id) VALUES (?, ?, ?, ?, ?, ?, ?)", messageID.String(), conversationID.String(), userID.String(), sql.NullString{String: content, Valid: content != ""}, sql.NullString{String: attachment, Valid: attachment != ""}, globaltime.Format(sentAt), sql.NullString{String: replyToMessageID.String(), Valid: replyToMessageID != uuid.Nil})
+	tx, err := repository.Database.Begin()
+	if err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("INSERT INTO messages (message_id, conversation_id, sender_id, content, sent_at, reply_to_message_id) VALUES (?, ?, ?, ?, ?, ?)", messageID.String(), conversationID.String(), userID.String(), sql.NullString{String: content, Valid: content != ""}, globaltime.Format(sentAt), sql.NullString{String: replyToMessageID.String(), Valid: replyToMessageID != uuid.Nil})
 	if err != nil {
 		return uuid.Nil, errors.ErrInternal
 	}
 
+	if err := insertAttachments(tx, messageID, attachments); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
 	return messageID, nil
 }
 
-func (repository *MessageRepository) CreateForwardedMessage(conversationID, userID, originalMessageID uuid.UUID) (uuid.UUID, error) {
-	messageRepository := MessageRepository{Database: repository.Database}
+func (repository *MessageRepository) CreateSystemMessage(conversationID, actorID uuid.UUID, event string, targetID uuid.UUID, content string) (uuid.UUID, error) {
+	messageID := uuid.New()
 
-	originalMessage, err := messageRepository.GetMessageByID(originalMessageID)
+	_, err := repository.Database.Exec("INSERT INTO messages (message_id, type, event, target_id, conversation_id, sender_id, content, sent_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)", messageID.String(), utils.MessageTypeSystem, event, sql.NullString{String: targetID.String(), Valid: targetID != uuid.Nil}, conversationID.String(), actorID.String(), sql.NullString{String: content, Valid: content != ""}, globaltime.Format(globaltime.Now()))
 	if err != nil {
-		return uuid.Nil, err
+		return uuid.Nil, errors.ErrInternal
 	}
 
-	forwardedMessageID := uuid.New()
+	return messageID, nil
+}
+
+type forwardOrigin struct {
+	SentAt, UserID, Username, CreatedAt     sql.NullString
+	ProfilePicture, ProfilePictureThumbnail sql.NullString
+}
+
+func (origin forwardOrigin) parse() (*models.ForwardedFrom, error) {
+	if !origin.SentAt.Valid {
+		return nil, nil
+	}
+
+	sentAt, err := globaltime.Parse(origin.SentAt.String)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	forwardedFrom := &models.ForwardedFrom{SentAt: sentAt}
+
+	if origin.UserID.Valid {
+		forwardedFrom.Sender.ID, err = uuid.Parse(origin.UserID.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		forwardedFrom.Sender.CreatedAt, err = globaltime.Parse(origin.CreatedAt.String)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		forwardedFrom.Sender.Username = origin.Username.String
+		forwardedFrom.Sender.ProfilePicture = origin.ProfilePicture.String
+		forwardedFrom.Sender.ProfilePictureThumbnail = origin.ProfilePictureThumbnail.String
+	}
+
+	return forwardedFrom, nil
+}
+
+func (repository *MessageRepository) CreateForwardedMessages(userID uuid.UUID, conversationIDs []uuid.UUID, originalMessages []models.Message) ([]uuid.UUID, error) {
 	forwardedAt := globaltime.Now()
 
 	tx, err := repository.Database.Begin()
 	if err != nil {
-		return uuid.Nil, errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
 	defer func() {
 		_ = tx.Rollback()
 	}()
 
-	_, err = repository.Database.Exec("INSERT INTO messages (message_id, content, attachment, sent_at, conversation_id, sender_id) VALUES (?, ?, ?, ?, ?, ?)", forwardedMessageID.String(), sql.NullString{String: originalMessage.Content, Valid: originalMessage.Content != ""}, sql.NullString{String: originalMessage.Attachment, Valid: originalMessage.Attachment != ""}, globaltime.Format(forwardedAt), conversationID.String(), userID.String())
+	forwardedMessageIDs := make([]uuid.UUID, 0, len(conversationIDs)*len(originalMessages))
+
+	for _, conversationID := range conversationIDs {
+		for _, originalMessage := range originalMessages {
+			forwardedMessageID := uuid.New()
+
+			originalSenderID := originalMessage.Sender.ID
+			originalSentAt := originalMessage.SentAt
+
+			if originalMessage.ForwardedFrom != nil {
+				originalSenderID = originalMessage.ForwardedFrom.Sender.ID
+				originalSentAt = originalMessage.ForwardedFrom.SentAt
+			}
+
+			_, err = tx.Exec("INSERT INTO messages (message_id, content, sent_at, conversation_id, sender_id) VALUES (?, ?, ?, ?, ?)", forwardedMessageID.String(), sql.NullString{String: originalMessage.Content, Valid: originalMessage.Content != ""}, globaltime.Format(forwardedAt), conversationID.String(), userID.String())
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+
+			if err := insertAttachments(tx, forwardedMessageID, originalMessage.Attachments); err != nil {
+				return nil, err
+			}
+
+			_, err = tx.Exec("INSERT INTO forwarded_messages (forwarded_message_id, forwarded_at, original_message_id, conversation_id, sender_id, original_sender_id, original_sent_at) VALUES (?, ?, ?, ?, ?, ?, ?)", forwardedMessageID.String(), globaltime.Format(forwardedAt), originalMessage.ID.String(), conversationID.String(), userID.String(), sql.NullString{String: originalSenderID.String(), Valid: originalSenderID != uuid.Nil}, globaltime.Format(originalSentAt))
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+
+			forwardedMessageIDs = append(forwardedMessageIDs, forwardedMessageID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return forwardedMessageIDs, nil
+}
+
+func (repository *MessageRepository) MarkMessagesDelivered(userID, conversationID uuid.UUID, deliveredAt time.Time) (map[uuid.UUID][]uuid.UUID, error) {
+	query := `SELECT m.message_id, m.conversation_id
+		 FROM messages m
+		 WHERE m.conversation_id IN (
+			SELECT conversation_id FROM participants WHERE user_id = ?
+			UNION
+			SELECT conversation_id FROM members WHERE user_id = ?
+		 )
+		 AND (m.sender_id IS NULL OR m.sender_id != ?)
+		 AND NOT EXISTS (
+			SELECT 1 FROM message_trackings t
+			WHERE t.message_id = m.message_id AND t.user_id = ? AND t.delivered_at IS NOT NULL
+		 )`
+
+	queryArgs := []interface{}{userID.String(), userID.String(), userID.String(), userID.String()}
+
+	if conversationID != uuid.Nil {
+		query += " AND m.conversation_id = ?"
+		queryArgs = append(queryArgs, conversationID.String())
+	}
+
+	pending, err := repository.queryMessageIDsByConversation(query, queryArgs...)
 	if err != nil {
-		return uuid.Nil, errors.ErrInternal
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return pending, nil
 	}
 
-	_, err = tx.Exec("INSERT INTO forwarded_messages (forwarded_message_id, forwarded_at, original_message_id, conversation_id, sender_id) VALUES (?, ?, ?, ?, ?)", forwardedMessageID.String(), globaltime.Format(forwardedAt), originalMessage.ID.String(), conversationID.String(), userID.String())
+	tx, err := repository.Database.Begin()
 	if err != nil {
-		return uuid.Nil, errors.ErrInternal
+		return nil, errors.ErrInternal
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, messageIDs := range pending {
+		for _, messageID := range messageIDs {
+			_, err := tx.Exec(`INSERT INTO message_trackings (message_id, user_id, delivered_at) VALUES (?, ?, ?)
+				 ON CONFLICT (message_id, user_id) DO UPDATE SET delivered_at = COALESCE(delivered_at, excluded.delivered_at)`, messageID.String(), userID.String(), globaltime.Format(deliveredAt))
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return uuid.Nil, errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
-	return forwardedMessageID, nil
+	return pending, nil
 }
 
-func (repository *MessageRepository) AddMessageTracking(messageID, userID uuid.UUID, readAt time.Time) error {
-	_, err := repository.Database.Exec(`INSERT INTO message_trackings (message_id, user_id, read_at) VALUES (?, ?, ?)`, messageID.String(), userID.String(), globaltime.Format(readAt))
+func (repository *MessageRepository) MarkMessagesRead(conversationID, userID, upToMessageID uuid.UUID, readAt time.Time) ([]uuid.UUID, error) {
+	var (
+		sentAt string
+		rowID  int64
+	)
+
+	err := repository.Database.QueryRow("SELECT sent_at, rowid FROM messages WHERE message_id = ? AND conversation_id = ?", upToMessageID.String(), conversationID.String()).Scan(&sentAt, &rowID)
 	if err != nil {
-		return errors.ErrInternal
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrNotFound
+		}
+
+		return nil, errors.ErrInternal
 	}
 
-	return nil
+	pending, err := repository.queryMessageIDsByConversation(`SELECT m.message_id, m.conversation_id
+		 FROM messages m
+		 WHERE m.conversation_id = ?
+		 AND (m.sender_id IS NULL OR m.sender_id != ?)
+		 AND (m.sent_at < ? OR (m.sent_at = ? AND m.rowid <= ?))
+		 AND NOT EXISTS (
+			SELECT 1 FROM message_trackings t
+			WHERE t.message_id = m.message_id AND t.user_id = ? AND t.read_at IS NOT NULL
+		 )`, conversationID.String(), userID.String(), sentAt, sentAt, rowID, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	messageIDs := pending[conversationID]
+	if len(messageIDs) == 0 {
+		return []uuid.UUID{}, nil
+	}
+
+	tx, err := repository.Database.Begin()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, messageID := range messageIDs {
+		_, err := tx.Exec(`INSERT INTO message_trackings (message_id, user_id, delivered_at, read_at) VALUES (?, ?, ?, ?)
+			 ON CONFLICT (message_id, user_id) DO UPDATE SET delivered_at = COALESCE(delivered_at, excluded.delivered_at), read_at = COALESCE(read_at, excluded.read_at)`, messageID.String(), userID.String(), globaltime.Format(readAt), globaltime.Format(readAt))
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return messageIDs, nil
 }
 
-func (repository *MessageRepository) UpdateMessage(messageID uuid.UUID, content string) error {
-	editedAt := globaltime.Now()
+func (repository *MessageRepository) CountUnreadMessages(conversationID, userID uuid.UUID) (int, error) {
+	var count int
+
+	err := repository.Database.QueryRow(`SELECT COUNT(*)
+		 FROM messages m
+		 WHERE m.conversation_id = ?
+		 AND m.type = 'text'
+		 AND (m.sender_id IS NULL OR m.sender_id != ?)
+		 AND m.deleted_at IS NULL
+		 AND NOT EXISTS (
+			SELECT 1 FROM message_trackings t
+			WHERE t.message_id = m.message_id AND t.user_id = ? AND t.read_at IS NOT NULL
+		 )
+		 AND NOT EXISTS (
+			SELECT 1 FROM hidden_messages h
+			WHERE h.message_id = m.message_id AND h.user_id = ?
+		 )`, conversationID.String(), userID.String(), userID.String(), userID.String()).Scan(&count)
+	if err != nil {
+		return 0, errors.ErrInternal
+	}
 
-	_, err := repository.Database.Exec("UPDATE messages SET content = ?, edited_at = ? WHERE message_id = ?", content, globaltime.Format(editedAt), messageID.String())
+	return count, nil
+}
+
+func (repository *MessageRepository) queryMessageIDsByConversation(query string, args ...interface{}) (map[uuid.UUID][]uuid.UUID, error) {
+	rows, err := repository.Database.Query(query, args...)
 	if err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
-	return nil
+	defer rows.Close()
+
+	messageIDs := map[uuid.UUID][]uuid.UUID{}
+
+	for rows.Next() {
+		var messageID, conversationID string
+
+		if err := rows.Scan(&messageID, &conversationID); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		mid, err := uuid.Parse(messageID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		cid, err := uuid.Parse(conversationID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		messageIDs[cid] = append(messageIDs[cid], mid)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return messageIDs, nil
 }
 
-func (repository *MessageRepository) DeleteMessage(messageID uuid.UUID) error {
-	var attachment sql.NullString
+func (repository *MessageRepository) GetMessageRevisions(messageID uuid.UUID) ([]models.MessageRevision, error) {
+	rows, err := repository.Database.Query("SELECT revision_id, content, created_at, replaced_at FROM message_revisions WHERE message_id = ? ORDER BY replaced_at ASC, rowid ASC", messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
 
-	err := repository.Database.QueryRow("SELECT attachment FROM messages WHERE message_id = ?", messageID.String()).Scan(&attachment)
-	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
-		return errors.ErrInternal
+	defer rows.Close()
+
+	revisions := []models.MessageRevision{}
+
+	for rows.Next() {
+		var (
+			revisionID, createdAt, replacedAt string
+			content                           sql.NullString
+		)
+
+		if err := rows.Scan(&revisionID, &content, &createdAt, &replacedAt); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		rid, err := uuid.Parse(revisionID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		createdAtTime, err := globaltime.Parse(createdAt)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		replacedAtTime, err := globaltime.Parse(replacedAt)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		revisions = append(revisions, models.MessageRevision{
+			ID:         rid,
+			Content:    content.String,
+			CreatedAt:  createdAtTime,
+			ReplacedAt: replacedAtTime,
+		})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
 	}
 
+	return revisions, nil
+}
+
+func (repository *MessageRepository) UpdateMessage(messageID uuid.UUID, content string) error {
+	editedAt := globaltime.Now()
+
 	tx, err := repository.Database.Begin()
 	if err != nil {
 		return errors.ErrInternal
@@ -617,34 +1467,105 @@ func (repository *MessageRepository) DeleteMessage(messageID uuid.UUID) error {
 		_ = tx.Rollback()
 	}()
 
-	_, err = tx.Exec("DELETE FROM message_trackings WHERE message_id = ?", messageID.String())
+	var (
+		previousContent, previousEditedAt sql.NullString
+		sentAt                            string
+	)
+
+	err = tx.QueryRow("SELECT content, sent_at, edited_at FROM messages WHERE message_id = ?", messageID.String()).Scan(&previousContent, &sentAt, &previousEditedAt)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.ErrNotFound
+		}
+
 		return errors.ErrInternal
 	}
 
-	_, err = tx.Exec("DELETE FROM forwarded_messages WHERE original_message_id = ?", messageID.String())
+	createdAt := sentAt
+	if previousEditedAt.Valid && previousEditedAt.String != "" {
+		createdAt = previousEditedAt.String
+	}
+
+	_, err = tx.Exec("INSERT INTO message_revisions (revision_id, message_id, content, created_at, replaced_at) VALUES (?, ?, ?, ?, ?)", uuid.New().String(), messageID.String(), previousContent, createdAt, globaltime.Format(editedAt))
 	if err != nil {
 		return errors.ErrInternal
 	}
 
-	_, err = tx.Exec("DELETE FROM comments WHERE message_id = ?", messageID.String())
+	_, err = tx.Exec("UPDATE messages SET content = ?, edited_at = ? WHERE message_id = ?", content, globaltime.Format(editedAt), messageID.String())
 	if err != nil {
 		return errors.ErrInternal
 	}
 
-	_, err = tx.Exec("DELETE FROM messages WHERE message_id = ?", messageID.String())
-	if err != nil {
+	if err := tx.Commit(); err != nil {
 		return errors.ErrInternal
 	}
 
+	return nil
+}
+
+func (repository *MessageRepository) DeleteMessage(messageID uuid.UUID) ([]string, error) {
+	attachmentRepository := AttachmentRepository{Database: repository.Database}
+
+	uploads, err := attachmentRepository.GetUploadsByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := repository.Database.Begin()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("DELETE FROM comments WHERE message_id = ?", messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM message_attachments WHERE message_id = ?", messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM message_revisions WHERE message_id = ?", messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM pinned_messages WHERE message_id = ?", messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("DELETE FROM starred_messages WHERE message_id = ?", messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	_, err = tx.Exec("UPDATE messages SET content = NULL, deleted_at = ? WHERE message_id = ?", globaltime.Format(globaltime.Now()), messageID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	uploads, err = unreferencedUploads(tx, uploads)
+	if err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(); err != nil {
-		return errors.ErrInternal
+		return nil, errors.ErrInternal
 	}
 
-	if attachment.Valid && attachment.String != "" {
-		if err := os.Remove("." + attachment.String); err != nil && !os.IsNotExist(err) {
-			return errors.ErrInternal
-		}
+	return uploads, nil
+}
+
+func (repository *MessageRepository) HideMessage(messageID, userID uuid.UUID) error {
+	_, err := repository.Database.Exec("INSERT INTO hidden_messages (message_id, user_id, hidden_at) VALUES (?, ?, ?) ON CONFLICT (message_id, user_id) DO NOTHING", messageID.String(), userID.String(), globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return errors.ErrInternal
 	}
 
 	return nil
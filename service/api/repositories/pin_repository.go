@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type PinRepository struct {
+	Database database.Database
+}
+
+func (repository *PinRepository) GetPinnedMessages(conversationID uuid.UUID) ([]models.PinnedMessage, error) {
+	rows, err := repository.Database.Query("SELECT message_id, pinned_by, pinned_at FROM pinned_messages WHERE conversation_id = ? ORDER BY pinned_at DESC, rowid DESC", conversationID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	type rawPin struct {
+		MessageID uuid.UUID
+		PinnedBy  uuid.UUID
+		PinnedAt  string
+	}
+
+	rawPins := []rawPin{}
+
+	for rows.Next() {
+		var (
+			messageID, pinnedAt string
+			pinnedBy            sql.NullString
+			pin                 rawPin
+		)
+
+		if err := rows.Scan(&messageID, &pinnedBy, &pinnedAt); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		pin.MessageID, err = uuid.Parse(messageID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		if pinnedBy.Valid {
+			pin.PinnedBy, err = uuid.Parse(pinnedBy.String)
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+		}
+
+		pin.PinnedAt = pinnedAt
+
+		rawPins = append(rawPins, pin)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	messageRepository := MessageRepository{Database: repository.Database}
+	userRepository := UserRepository{Database: repository.Database}
+
+	pins := make([]models.PinnedMessage, 0, len(rawPins))
+
+	for _, rp := range rawPins {
+		message, err := messageRepository.GetMessageByID(rp.MessageID)
+		if err != nil {
+			return nil, err
+		}
+
+		if message == nil {
+			continue
+		}
+
+		pin := models.PinnedMessage{Message: *message}
+
+		if rp.PinnedBy != uuid.Nil {
+			pinnedBy, err := userRepository.GetUserByID(rp.PinnedBy)
+			if err != nil {
+				return nil, err
+			}
+
+			if pinnedBy != nil {
+				pin.PinnedBy = *pinnedBy
+			}
+		}
+
+		pin.PinnedAt, err = globaltime.Parse(rp.PinnedAt)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		pins = append(pins, pin)
+	}
+
+	return pins, nil
+}
+
+func (repository *PinRepository) CountPinnedMessages(conversationID uuid.UUID) (int, error) {
+	var count int
+
+	if err := repository.Database.QueryRow("SELECT COUNT(*) FROM pinned_messages WHERE conversation_id = ?", conversationID.String()).Scan(&count); err != nil {
+		return 0, errors.ErrInternal
+	}
+
+	return count, nil
+}
+
+func (repository *PinRepository) IsMessagePinned(conversationID, messageID uuid.UUID) (bool, error) {
+	var pinned bool
+
+	err := repository.Database.QueryRow("SELECT EXISTS (SELECT 1 FROM pinned_messages WHERE conversation_id = ? AND message_id = ?)", conversationID.String(), messageID.String()).Scan(&pinned)
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return pinned, nil
+}
+
+func (repository *PinRepository) PinMessage(conversationID, messageID, userID uuid.UUID) error {
+	_, err := repository.Database.Exec("INSERT INTO pinned_messages (conversation_id, message_id, pinned_by, pinned_at) VALUES (?, ?, ?, ?)", conversationID.String(), messageID.String(), userID.String(), globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *PinRepository) UnpinMessage(conversationID, messageID uuid.UUID) error {
+	_, err := repository.Database.Exec("DELETE FROM pinned_messages WHERE conversation_id = ? AND message_id = ?", conversationID.String(), messageID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"database/sql"
+	stdErrors "errors"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type ScheduledMessageRepository struct {
+	Database database.Database
+}
+
+func (repository *ScheduledMessageRepository) GetScheduledMessages(conversationID, senderID uuid.UUID) ([]models.ScheduledMessage, error) {
+	return repository.queryScheduledMessages("SELECT scheduled_message_id, conversation_id, sender_id, content, reply_to_message_id, created_at, send_at, status, failed_at, failure_reason FROM scheduled_messages WHERE conversation_id = ? AND sender_id = ? ORDER BY send_at ASC, rowid ASC", conversationID.String(), senderID.String())
+}
+
+func (repository *ScheduledMessageRepository) GetDueScheduledMessages(now time.Time, limit int) ([]models.ScheduledMessage, error) {
+	return repository.queryScheduledMessages("SELECT scheduled_message_id, conversation_id, sender_id, content, reply_to_message_id, created_at, send_at, status, failed_at, failure_reason FROM scheduled_messages WHERE send_at <= ? AND (status = 'pending' OR (status = 'sending' AND claimed_until <= ?)) ORDER BY send_at ASC, rowid ASC LIMIT ?", globaltime.Format(now.UTC()), globaltime.Format(now.UTC()), limit)
+}
+
+func (repository *ScheduledMessageRepository) queryScheduledMessages(query string, args ...interface{}) ([]models.ScheduledMessage, error) {
+	rows, err := repository.Database.Query(query, args...)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	scheduledMessages := []models.ScheduledMessage{}
+
+	for rows.Next() {
+		var (
+			scheduledMessageID, conversationID, senderID string
+			content, createdAt, sendAt, status           string
+			replyToMessageID, failedAt, failureReason    sql.NullString
+			scheduledMessage                             models.ScheduledMessage
+		)
+
+		if err := rows.Scan(&scheduledMessageID, &conversationID, &senderID, &content, &replyToMessageID, &createdAt, &sendAt, &status, &failedAt, &failureReason); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		scheduledMessage.ID, err = uuid.Parse(scheduledMessageID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		scheduledMessage.ConversationID, err = uuid.Parse(conversationID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		scheduledMessage.SenderID, err = uuid.Parse(senderID)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		scheduledMessage.Content = content
+
+		if replyToMessageID.Valid {
+			scheduledMessage.ReplyToMessageID, err = uuid.Parse(replyToMessageID.String)
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+		}
+
+		scheduledMessage.CreatedAt, err = globaltime.Parse(createdAt)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		scheduledMessage.SendAt, err = globaltime.Parse(sendAt)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		scheduledMessage.Status = status
+
+		if failedAt.Valid {
+			t, err := globaltime.Parse(failedAt.String)
+			if err != nil {
+				return nil, errors.ErrInternal
+			}
+
+			scheduledMessage.FailedAt = &t
+		}
+
+		scheduledMessage.FailureReason = failureReason.String
+
+		scheduledMessages = append(scheduledMessages, scheduledMessage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return scheduledMessages, nil
+}
+
+func (repository *ScheduledMessageRepository) CountScheduledMessages(conversationID, senderID uuid.UUID) (int, error) {
+	var count int
+
+	err := repository.Database.QueryRow("SELECT COUNT(*) FROM scheduled_messages WHERE conversation_id = ? AND sender_id = ?", conversationID.String(), senderID.String()).Scan(&count)
+	if err != nil {
+		return 0, errors.ErrInternal
+	}
+
+	return count, nil
+}
+
+func (repository *ScheduledMessageRepository) CreateScheduledMessage(conversationID, senderID uuid.UUID, content string, replyToMessageID uuid.UUID, sendAt time.Time) (*models.ScheduledMessage, error) {
+	scheduledMessage := models.ScheduledMessage{
+		ID:               uuid.New(),
+		ConversationID:   conversationID,
+		SenderID:         senderID,
+		Content:          content,
+		ReplyToMessageID: replyToMessageID,
+		CreatedAt:        globaltime.Now().Truncate(time.Second),
+		SendAt:           sendAt.UTC().Truncate(time.Second),
+		Status:           utils.ScheduledMessageStatusPending,
+	}
+
+	_, err := repository.Database.Exec("INSERT INTO scheduled_messages (scheduled_message_id, conversation_id, sender_id, content, reply_to_message_id, created_at, send_at) VALUES (?, ?, ?, ?, ?, ?, ?)", scheduledMessage.ID.String(), conversationID.String(), senderID.String(), content, sql.NullString{String: replyToMessageID.String(), Valid: replyToMessageID != uuid.Nil}, globaltime.Format(scheduledMessage.CreatedAt), globaltime.Format(scheduledMessage.SendAt))
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return &scheduledMessage, nil
+}
+
+func (repository *ScheduledMessageRepository) CancelScheduledMessage(conversationID, senderID, scheduledMessageID uuid.UUID) (bool, error) {
+	cancelled, err := repository.execScheduledMessage("DELETE FROM scheduled_messages WHERE scheduled_message_id = ? AND conversation_id = ? AND sender_id = ? AND status != 'sending'", scheduledMessageID.String(), conversationID.String(), senderID.String())
+	if err != nil || cancelled {
+		return cancelled, err
+	}
+
+	var status string
+
+	err = repository.Database.QueryRow("SELECT status FROM scheduled_messages WHERE scheduled_message_id = ? AND conversation_id = ? AND sender_id = ?", scheduledMessageID.String(), conversationID.String(), senderID.String()).Scan(&status)
+	if stdErrors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return false, errors.ErrConflict
+}
+
+func (repository *ScheduledMessageRepository) ClaimScheduledMessage(scheduledMessageID uuid.UUID, now time.Time, lease time.Duration) (bool, error) {
+	return repository.execScheduledMessage("UPDATE scheduled_messages SET status = 'sending', claimed_until = ? WHERE scheduled_message_id = ? AND (status = 'pending' OR (status = 'sending' AND claimed_until <= ?))", globaltime.Format(now.UTC().Add(lease)), scheduledMessageID.String(), globaltime.Format(now.UTC()))
+}
+
+func (repository *ScheduledMessageRepository) CompleteScheduledMessage(scheduledMessageID uuid.UUID) (bool, error) {
+	return repository.execScheduledMessage("DELETE FROM scheduled_messages WHERE scheduled_message_id = ? AND status = 'sending'", scheduledMessageID.String())
+}
+
+func (repository *ScheduledMessageRepository) FailScheduledMessage(scheduledMessageID uuid.UUID, failedAt time.Time, reason string) (bool, error) {
+	return repository.execScheduledMessage("UPDATE scheduled_messages SET status = 'failed', claimed_until = NULL, failed_at = ?, failure_reason = ? WHERE scheduled_message_id = ? AND status = 'sending'", globaltime.Format(failedAt.UTC()), reason, scheduledMessageID.String())
+}
+
+func (repository *ScheduledMessageRepository) execScheduledMessage(query string, args ...interface{}) (bool, error) {
+	result, err := repository.Database.Exec(query, args...)
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return affected > 0, nil
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type SessionRepository struct {
+	Database database.Database
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (repository *SessionRepository) GetActiveSessionByToken(token string) (*models.Session, error) {
+	row := repository.Database.QueryRow("SELECT session_id, user_id, created_at, expires_at FROM sessions WHERE token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hashSessionToken(token), globaltime.Format(globaltime.Now()))
+
+	var sessionID, userID, createdAt, expiresAt string
+
+	if err := row.Scan(&sessionID, &userID, &createdAt, &expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, errors.ErrInternal
+	}
+
+	return repository.buildSession(sessionID, userID, createdAt, expiresAt)
+}
+
+func (repository *SessionRepository) GetSessionByID(sessionID uuid.UUID) (*models.Session, error) {
+	row := repository.Database.QueryRow("SELECT session_id, user_id, created_at, expires_at FROM sessions WHERE session_id = ? AND revoked_at IS NULL", sessionID.String())
+
+	var id, userID, createdAt, expiresAt string
+
+	if err := row.Scan(&id, &userID, &createdAt, &expiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, errors.ErrInternal
+	}
+
+	return repository.buildSession(id, userID, createdAt, expiresAt)
+}
+
+func (repository *SessionRepository) GetActiveSessionsByUserID(userID uuid.UUID) ([]models.Session, error) {
+	rows, err := repository.Database.Query("SELECT session_id, user_id, created_at, expires_at FROM sessions WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY created_at DESC", userID.String(), globaltime.Format(globaltime.Now()))
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	type rawSession struct {
+		ID, UserID, CreatedAt, ExpiresAt string
+	}
+
+	rawSessions := []rawSession{}
+
+	for rows.Next() {
+		var rs rawSession
+
+		if err := rows.Scan(&rs.ID, &rs.UserID, &rs.CreatedAt, &rs.ExpiresAt); err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		rawSessions = append(rawSessions, rs)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	sessions := make([]models.Session, 0, len(rawSessions))
+
+	for _, rs := range rawSessions {
+		session, err := repository.buildSession(rs.ID, rs.UserID, rs.CreatedAt, rs.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if session == nil {
+			continue
+		}
+
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, nil
+}
+
+func (repository *SessionRepository) CreateSession(userID uuid.UUID, token string, expiresAt time.Time) (uuid.UUID, error) {
+	sessionID := uuid.New()
+	createdAt := globaltime.Now()
+
+	_, err := repository.Database.Exec("INSERT INTO sessions (session_id, token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?, ?)", sessionID.String(), hashSessionToken(token), userID.String(), globaltime.Format(createdAt), globaltime.Format(expiresAt))
+	if err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
+	return sessionID, nil
+}
+
+func (repository *SessionRepository) RevokeSession(sessionID uuid.UUID) error {
+	_, err := repository.Database.Exec("UPDATE sessions SET revoked_at = ? WHERE session_id = ? AND revoked_at IS NULL", globaltime.Format(globaltime.Now()), sessionID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *SessionRepository) RevokeSessionsByUserID(userID uuid.UUID, exceptSessionID uuid.UUID) error {
+	_, err := repository.Database.Exec("UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND session_id != ? AND revoked_at IS NULL", globaltime.Format(globaltime.Now()), userID.String(), exceptSessionID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func (repository *SessionRepository) buildSession(sessionID, userID, createdAt, expiresAt string) (*models.Session, error) {
+	var session models.Session
+
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	session.ID = sid
+
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	userRepository := UserRepository{Database: repository.Database}
+
+	user, err := userRepository.GetUserByID(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		return nil, nil
+	}
+
+	session.User = *user
+
+	session.CreatedAt, err = globaltime.Parse(createdAt)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	session.ExpiresAt, err = globaltime.Parse(expiresAt)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return &session, nil
+}
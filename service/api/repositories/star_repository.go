@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type StarRepository struct {
+	Database database.Database
+}
+
+func (repository *StarRepository) GetStarredMessages(userID uuid.UUID, before string, limit int) ([]models.StarredMessage, string, error) {
+	query := `SELECT s.rowid, s.message_id, m.conversation_id, s.starred_at
+		 FROM starred_messages s
+		 JOIN messages m ON m.message_id = s.message_id
+		 WHERE s.user_id = ?
+		 AND NOT EXISTS (
+			SELECT 1 FROM hidden_messages h
+			WHERE h.message_id = s.message_id AND h.user_id = s.user_id
+		 )`
+
+	queryArgs := []interface{}{userID.String()}
+
+	if before != "" {
+		beforeStarredAt, beforeRowID, err := decodeMessageCursor(before)
+		if err != nil {
+			return nil, "", err
+		}
+
+		query += " AND (s.starred_at < ? OR (s.starred_at = ? AND s.rowid < ?))"
+		queryArgs = append(queryArgs, beforeStarredAt, beforeStarredAt, beforeRowID)
+	}
+
+	query += " ORDER BY s.starred_at DESC, s.rowid DESC LIMIT ?"
+	queryArgs = append(queryArgs, limit+1)
+
+	rows, err := repository.Database.Query(query, queryArgs...)
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	defer rows.Close()
+
+	type rawStar struct {
+		RowID          int64
+		MessageID      uuid.UUID
+		ConversationID uuid.UUID
+		StarredAt      string
+	}
+
+	rawStars := []rawStar{}
+
+	for rows.Next() {
+		var (
+			rs                        rawStar
+			messageID, conversationID string
+		)
+
+		if err := rows.Scan(&rs.RowID, &messageID, &conversationID, &rs.StarredAt); err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		rs.MessageID, err = uuid.Parse(messageID)
+		if err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		rs.ConversationID, err = uuid.Parse(conversationID)
+		if err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		rawStars = append(rawStars, rs)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	var nextCursor string
+	if len(rawStars) > limit {
+		rawStars = rawStars[:limit]
+		last := rawStars[len(rawStars)-1]
+		nextCursor = encodeMessageCursor(last.StarredAt, last.RowID)
+	}
+
+	messageRepository := MessageRepository{Database: repository.Database}
+
+	stars := make([]models.StarredMessage, 0, len(rawStars))
+
+	for _, rs := range rawStars {
+		message, err := messageRepository.GetMessageByID(rs.MessageID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if message == nil {
+			continue
+		}
+
+		starredAt, err := globaltime.Parse(rs.StarredAt)
+		if err != nil {
+			return nil, "", errors.ErrInternal
+		}
+
+		stars = append(stars, models.StarredMessage{
+			ConversationID: rs.ConversationID,
+			Message:        *message,
+			StarredAt:      starredAt,
+		})
+	}
+
+	return stars, nextCursor, nil
+}
+
+func (repository *StarRepository) IsMessageStarred(userID, messageID uuid.UUID) (bool, error) {
+	var starred bool
+
+	err := repository.Database.QueryRow("SELECT EXISTS (SELECT 1 FROM starred_messages WHERE user_id = ? AND message_id = ?)", userID.String(), messageID.String()).Scan(&starred)
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return starred, nil
+}
+
+func (repository *StarRepository) StarMessage(userID, messageID uuid.UUID) (time.Time, error) {
+	starredAt := globaltime.Now().Truncate(time.Second)
+
+	_, err := repository.Database.Exec("INSERT INTO starred_messages (user_id, message_id, starred_at) VALUES (?, ?, ?)", userID.String(), messageID.String(), globaltime.Format(starredAt))
+	if err != nil {
+		return time.Time{}, errors.ErrInternal
+	}
+
+	return starredAt, nil
+}
+
+func (repository *StarRepository) UnstarMessage(userID, messageID uuid.UUID) error {
+	_, err := repository.Database.Exec("DELETE FROM starred_messages WHERE user_id = ? AND message_id = ?", userID.String(), messageID.String())
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
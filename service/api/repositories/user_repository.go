@@ -3,7 +3,6 @@ package repositories
 import (
 	"database/sql"
 	stdErrors "errors"
-	"os"
 
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/database"
@@ -17,9 +16,12 @@ type UserRepository struct {
 }
 
 func (repository *UserRepository) GetUsers(q string, authenticatedUserID uuid.UUID) ([]models.User, error) {
-	query := "SELECT user_id, username, profile_picture, created_at FROM users WHERE user_id != ?"
+	query := `SELECT user_id, username, profile_picture, profile_picture_thumbnail, created_at FROM users
+		WHERE user_id != ?
+		AND user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)
+		AND user_id NOT IN (SELECT blocker_id FROM blocks WHERE blocked_id = ?)`
 
-	args := []interface{}{authenticatedUserID}
+	args := []interface{}{authenticatedUserID, authenticatedUserID.String(), authenticatedUserID.String()}
 	if q != "" {
 		query += " AND username LIKE ?"
 		args = append(args, q+"%")
@@ -38,11 +40,11 @@ func (repository *UserRepository) GetUsers(q string, authenticatedUserID uuid.UU
 		var user models.User
 
 		var (
-			userID, createdAt string
-			profilePicture    sql.NullString
+			userID, createdAt                       string
+			profilePicture, profilePictureThumbnail sql.NullString
 		)
 
-		if err := rows.Scan(&userID, &user.Username, &profilePicture, &createdAt); err != nil {
+		if err := rows.Scan(&userID, &user.Username, &profilePicture, &profilePictureThumbnail, &createdAt); err != nil {
 			return nil, errors.ErrInternal
 		}
 
@@ -57,6 +59,10 @@ func (repository *UserRepository) GetUsers(q string, authenticatedUserID uuid.UU
 			user.ProfilePicture = profilePicture.String
 		}
 
+		if profilePictureThumbnail.Valid {
+			user.ProfilePictureThumbnail = profilePictureThumbnail.String
+		}
+
 		user.CreatedAt, err = globaltime.Parse(createdAt)
 		if err != nil {
 			return nil, errors.ErrInternal
@@ -73,16 +79,16 @@ func (repository *UserRepository) GetUsers(q string, authenticatedUserID uuid.UU
 }
 
 func (repository *UserRepository) GetUserByID(userID uuid.UUID) (*models.User, error) {
-	row := repository.Database.QueryRow("SELECT username, profile_picture, created_at FROM users WHERE user_id = ?", userID.String())
+	row := repository.Database.QueryRow("SELECT username, profile_picture, profile_picture_thumbnail, created_at FROM users WHERE user_id = ?", userID.String())
 
 	var user models.User
 
 	var (
-		createdAt      string
-		profilePicture sql.NullString
+		createdAt                               string
+		profilePicture, profilePictureThumbnail sql.NullString
 	)
 
-	if err := row.Scan(&user.Username, &profilePicture, &createdAt); err != nil {
+	if err := row.Scan(&user.Username, &profilePicture, &profilePictureThumbnail, &createdAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -96,6 +102,10 @@ func (repository *UserRepository) GetUserByID(userID uuid.UUID) (*models.User, e
 		user.ProfilePicture = profilePicture.String
 	}
 
+	if profilePictureThumbnail.Valid {
+		user.ProfilePictureThumbnail = profilePictureThumbnail.String
+	}
+
 	var err error
 
 	user.CreatedAt, err = globaltime.Parse(createdAt)
@@ -107,16 +117,16 @@ func (repository *UserRepository) GetUserByID(userID uuid.UUID) (*models.User, e
 }
 
 func (repository *UserRepository) GetUserByUsername(username string) (*models.User, error) {
-	row := repository.Database.QueryRow("SELECT user_id, username, profile_picture, created_at FROM users WHERE username = ?", username)
+	row := repository.Database.QueryRow("SELECT user_id, username, profile_picture, profile_picture_thumbnail, created_at FROM users WHERE username = ?", username)
 
 	var user models.User
 
 	var (
-		userID, createdAt string
-		profilePicture    sql.NullString
+		userID, createdAt                       string
+		profilePicture, profilePictureThumbnail sql.NullString
 	)
 
-	if err := row.Scan(&userID, &user.Username, &profilePicture, &createdAt); err != nil {
+	if err := row.Scan(&userID, &user.Username, &profilePicture, &profilePictureThumbnail, &createdAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -135,6 +145,10 @@ func (repository *UserRepository) GetUserByUsername(username string) (*models.Us
 		user.ProfilePicture = profilePicture.String
 	}
 
+	if profilePictureThumbnail.Valid {
+		user.ProfilePictureThumbnail = profilePictureThumbnail.String
+	}
+
 	user.CreatedAt, err = globaltime.Parse(createdAt)
 	if err != nil {
 		return nil, errors.ErrInternal
@@ -143,15 +157,35 @@ func (repository *UserRepository) GetUserByUsername(username string) (*models.Us
 	return &user, nil
 }
 
-func (repository *UserRepository) CreateUser(username string) (uuid.UUID, error) {
+func (repository *UserRepository) CreateUser(username string, passwordHash string) (uuid.UUID, error) {
 	userID := uuid.New()
 	createdAt := globaltime.Now()
 
-	_, err := repository.Database.Exec("INSERT INTO users (user_id, username, created_at) VALUES (?, ?, ?)", userID.String(), username, globaltime.Format(createdAt))
+	tx, err := repository.Database.Begin()
+	if err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.Exec("INSERT INTO users (user_id, username, created_at) VALUES (?, ?, ?)", userID.String(), username, globaltime.Format(createdAt))
 	if err != nil {
 		return uuid.Nil, errors.ErrInternal
 	}
 
+	if passwordHash != "" {
+		_, err = tx.Exec("INSERT INTO user_credentials (user_id, password_hash, updated_at) VALUES (?, ?, ?)", userID.String(), passwordHash, globaltime.Format(createdAt))
+		if err != nil {
+			return uuid.Nil, errors.ErrInternal
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, errors.ErrInternal
+	}
+
 	return userID, nil
 }
 
@@ -164,24 +198,41 @@ func (repository *UserRepository) UpdateUsername(userID uuid.UUID, username stri
 	return nil
 }
 
-func (repository *UserRepository) UpdateProfilePicture(userID uuid.UUID, profilePicture string) error {
-	var oldProfilePicture sql.NullString
+func (repository *UserRepository) GetPrivacySettings(userID uuid.UUID) (*models.PrivacySettings, error) {
+	var settings models.PrivacySettings
 
-	err := repository.Database.QueryRow("SELECT profile_picture FROM users WHERE user_id = ?", userID.String()).Scan(&oldProfilePicture)
-	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
-		return errors.ErrInternal
+	if err := repository.Database.QueryRow("SELECT group_add_privacy FROM users WHERE user_id = ?", userID.String()).Scan(&settings.GroupAdd); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, errors.ErrInternal
 	}
 
-	_, err = repository.Database.Exec("UPDATE users SET profile_picture = ? WHERE user_id = ?", sql.NullString{String: profilePicture, Valid: profilePicture != ""}, userID.String())
+	return &settings, nil
+}
+
+func (repository *UserRepository) UpdatePrivacySettings(userID uuid.UUID, settings models.PrivacySettings) error {
+	_, err := repository.Database.Exec("UPDATE users SET group_add_privacy = ? WHERE user_id = ?", settings.GroupAdd, userID.String())
 	if err != nil {
 		return errors.ErrInternal
 	}
 
-	if oldProfilePicture.Valid && oldProfilePicture.String != "" {
-		if err := os.Remove("." + oldProfilePicture.String); err != nil && !os.IsNotExist(err) {
-			return errors.ErrInternal
-		}
+	return nil
+}
+
+func (repository *UserRepository) UpdateProfilePicture(userID uuid.UUID, profilePicture, profilePictureThumbnail string) ([]string, error) {
+	var oldProfilePicture, oldProfilePictureThumbnail sql.NullString
+
+	err := repository.Database.QueryRow("SELECT profile_picture, profile_picture_thumbnail FROM users WHERE user_id = ?", userID.String()).Scan(&oldProfilePicture, &oldProfilePictureThumbnail)
+	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, errors.ErrInternal
 	}
 
-	return nil
+	_, err = repository.Database.Exec("UPDATE users SET profile_picture = ?, profile_picture_thumbnail = ? WHERE user_id = ?", sql.NullString{String: profilePicture, Valid: profilePicture != ""}, sql.NullString{String: profilePictureThumbnail, Valid: profilePictureThumbnail != ""}, userID.String())
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return []string{oldProfilePicture.String, oldProfilePictureThumbnail.String}, nil
 }
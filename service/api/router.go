@@ -3,19 +3,35 @@ package api
 import (
 	"errors"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/evaevangelisti/wasatext/service/api/events"
 	"github.com/evaevangelisti/wasatext/service/api/handlers"
 	"github.com/evaevangelisti/wasatext/service/api/middlewares"
 	"github.com/evaevangelisti/wasatext/service/api/repositories"
 	"github.com/evaevangelisti/wasatext/service/api/services"
 	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/media"
+	"github.com/evaevangelisti/wasatext/service/storage"
 	"github.com/julienschmidt/httprouter"
 	"github.com/sirupsen/logrus"
 )
 
 type Config struct {
-	Logger   logrus.FieldLogger
-	Database database.Database
+	Logger      logrus.FieldLogger
+	Database    database.Database
+	Store       storage.BlobStore
+	Signer      *storage.URLSigner
+	Attachments media.Policy
+	SessionTTL  time.Duration
+	EditWindow  time.Duration
+
+	TransferTimeout time.Duration
+
+	DispatchInterval time.Duration
+
+	ReactionDetailsMaxMembers int
 }
 
 type Router interface {
@@ -24,9 +40,24 @@ type Router interface {
 }
 
 type routerImpl struct {
-	httpRouter *httprouter.Router
-	logger     logrus.FieldLogger
-	database   database.Database
+	httpRouter  *httprouter.Router
+	logger      logrus.FieldLogger
+	database    database.Database
+	store       storage.BlobStore
+	signer      *storage.URLSigner
+	attachments media.Policy
+	sessionTTL  time.Duration
+	editWindow  time.Duration
+	events      *events.Hub
+
+	transferTimeout time.Duration
+
+	dispatchInterval time.Duration
+	dispatcherStop   chan struct{}
+	dispatcherDone   sync.WaitGroup
+	closeOnce        sync.Once
+
+	reactionDetailsMaxMembers int
 }
 
 func New(config Config) (Router, error) {
@@ -38,16 +69,73 @@ func New(config Config) (Router, error) {
 		return nil, errors.New("database is required")
 	}
 
+	if config.Store == nil {
+		return nil, errors.New("blob store is required")
+	}
+
+	if config.Signer == nil {
+		return nil, errors.New("URL signer is required")
+	}
+
+	for _, size := range config.Attachments.MaxSizes {
+		if size <= 0 {
+			return nil, errors.New("attachment size limits must be positive")
+		}
+	}
+
+	if config.Attachments.MaxTotalSize < config.Attachments.MaxSize() {
+		return nil, errors.New("attachment total size limit must not be smaller than the per-file limits")
+	}
+
+	if config.SessionTTL <= 0 {
+		return nil, errors.New("session TTL must be positive")
+	}
+
+	if config.EditWindow < 0 {
+		return nil, errors.New("edit window must not be negative")
+	}
+
+	if config.TransferTimeout < 0 {
+		return nil, errors.New("transfer timeout must not be negative")
+	}
+
+	if config.DispatchInterval < 0 {
+		return nil, errors.New("dispatch interval must not be negative")
+	}
+
+	if config.ReactionDetailsMaxMembers < 0 {
+		return nil, errors.New("reaction details member limit must not be negative")
+	}
+
 	httpRouter := httprouter.New()
 
 	httpRouter.RedirectTrailingSlash = false
 	httpRouter.RedirectFixedPath = false
 
-	return &routerImpl{
-		httpRouter: httpRouter,
-		logger:     config.Logger,
-		database:   config.Database,
-	}, nil
+	router := &routerImpl{
+		httpRouter:  httpRouter,
+		logger:      config.Logger,
+		database:    config.Database,
+		store:       config.Store,
+		signer:      config.Signer,
+		attachments: config.Attachments,
+		sessionTTL:  config.SessionTTL,
+		editWindow:  config.EditWindow,
+		events:      events.NewHub(),
+
+		transferTimeout: config.TransferTimeout,
+
+		dispatchInterval: config.DispatchInterval,
+		dispatcherStop:   make(chan struct{}),
+
+		reactionDetailsMaxMembers: config.ReactionDetailsMaxMembers,
+	}
+
+	if router.dispatchInterval > 0 {
+		router.startDispatcher()
+	}
+
+	return router, nil
 }
 
 func (router *routerImpl) Handler() http.Handler {
@@ -56,12 +144,26 @@ func (router *routerImpl) Handler() http.Handler {
 	httpRouter.GET("/liveness", handlers.Liveness(router.database))
 
 	userRepository := &repositories.UserRepository{Database: router.database}
-	userService := &services.UserService{Repository: userRepository}
-	userHandler := &handlers.UserHandler{Service: userService}
+	userService := &services.UserService{Repository: userRepository, Store: router.store}
+	userHandler := &handlers.UserHandler{Service: userService, TransferTimeout: router.transferTimeout}
+
+	sessionRepository := &repositories.SessionRepository{Database: router.database}
+	sessionService := &services.SessionService{Repository: sessionRepository, TTL: router.sessionTTL}
+	sessionHandler := &handlers.SessionHandler{Service: sessionService, UserService: userService}
 
 	withAuth := func(handler httprouter.Handle) httprouter.Handle {
 		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-			middlewareHandler := middlewares.AuthMiddleware(userRepository, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			middlewareHandler := middlewares.AuthMiddleware(sessionRepository, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler(w, r, ps)
+			}))
+
+			middlewareHandler.ServeHTTP(w, r)
+		}
+	}
+
+	withOptionalAuth := func(handler httprouter.Handle) httprouter.Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+			middlewareHandler := middlewares.OptionalAuthMiddleware(sessionRepository, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				handler(w, r, ps)
 			}))
 
@@ -69,42 +171,120 @@ func (router *routerImpl) Handler() http.Handler {
 		}
 	}
 
+	httpRouter.POST("/session", sessionHandler.DoLogin)
+	httpRouter.DELETE("/session", withAuth(sessionHandler.DoLogout))
+	httpRouter.GET("/me/sessions", withAuth(sessionHandler.GetMySessions))
+	httpRouter.DELETE("/me/sessions/:sessionId", withAuth(sessionHandler.RevokeSession))
+
 	httpRouter.GET("/users", withAuth(userHandler.GetUsers))
-	httpRouter.POST("/users", userHandler.DoLogin)
+	httpRouter.POST("/users", sessionHandler.DoLogin)
 	httpRouter.PUT("/me/username", withAuth(userHandler.SetMyUserName))
 	httpRouter.PUT("/me/photo", withAuth(userHandler.SetMyPhoto))
+	httpRouter.PUT("/me/password", withAuth(userHandler.SetMyPassword))
+	httpRouter.GET("/me/blocks", withAuth(userHandler.GetMyBlocks))
+	httpRouter.POST("/me/blocks", withAuth(userHandler.BlockUser))
+	httpRouter.DELETE("/me/blocks/:userId", withAuth(userHandler.UnblockUser))
+	httpRouter.GET("/me/privacy", withAuth(userHandler.GetMyPrivacy))
+	httpRouter.PUT("/me/privacy", withAuth(userHandler.SetMyPrivacy))
 
 	conversationRepository := &repositories.ConversationRepository{Database: router.database}
-	conversationService := &services.ConversationService{Repository: conversationRepository}
-	conversationHandler := &handlers.ConversationHandler{Service: conversationService}
+	conversationService := &services.ConversationService{Repository: conversationRepository, Events: router.events, Store: router.store, Signer: router.signer, ReactionDetailsMaxMembers: router.reactionDetailsMaxMembers}
+	conversationHandler := &handlers.ConversationHandler{Service: conversationService, TransferTimeout: router.transferTimeout}
 
 	httpRouter.GET("/conversations", withAuth(conversationHandler.GetMyConversations))
 	httpRouter.GET("/conversations/:conversationId", withAuth(conversationHandler.GetConversation))
 	httpRouter.POST("/conversations", withAuth(conversationHandler.CreateConversation))
+	httpRouter.PATCH("/conversations/:conversationId/settings", withAuth(conversationHandler.SetConversationSettings))
+	httpRouter.POST("/conversations/:conversationId/pins", withAuth(conversationHandler.PinMessage))
+	httpRouter.DELETE("/conversations/:conversationId/pins/:messageId", withAuth(conversationHandler.UnpinMessage))
 	httpRouter.POST("/groups/:conversationId/members", withAuth(conversationHandler.AddToGroup))
 	httpRouter.PUT("/groups/:conversationId/name", withAuth(conversationHandler.SetGroupName))
 	httpRouter.PUT("/groups/:conversationId/photo", withAuth(conversationHandler.SetGroupPhoto))
-	httpRouter.DELETE("/groups/:conversationId/members/me", withAuth(conversationHandler.LeaveGroup))
+	httpRouter.DELETE("/groups/:conversationId/members/:userId", withAuth(conversationHandler.RemoveFromGroup))
+	httpRouter.PUT("/groups/:conversationId/members/:userId/role", withAuth(conversationHandler.SetMemberRole))
+	httpRouter.PUT("/groups/:conversationId/permissions", withAuth(conversationHandler.SetGroupPermissions))
+	httpRouter.GET("/groups/:conversationId/invites", withAuth(conversationHandler.GetGroupInvites))
+	httpRouter.POST("/groups/:conversationId/invites", withAuth(conversationHandler.CreateGroupInvite))
+	httpRouter.DELETE("/groups/:conversationId/invites/:inviteId", withAuth(conversationHandler.RevokeGroupInvite))
+	httpRouter.POST("/invites/:token/join", withAuth(conversationHandler.JoinGroup))
 
 	messageRepository := &repositories.MessageRepository{Database: router.database}
-	messageService := &services.MessageService{Repository: messageRepository}
-	messageHandler := &handlers.MessageHandler{Service: messageService}
+	messageService := &services.MessageService{Repository: messageRepository, Events: router.events, Store: router.store, Signer: router.signer, EditWindow: router.editWindow, ReactionDetailsMaxMembers: router.reactionDetailsMaxMembers}
+	messageHandler := &handlers.MessageHandler{Service: messageService, Attachments: router.attachments, TransferTimeout: router.transferTimeout}
 
+	httpRouter.GET("/conversations/:conversationId/messages", withAuth(messageHandler.GetMessages))
 	httpRouter.POST("/conversations/:conversationId/messages", withAuth(messageHandler.SendMessage))
+	httpRouter.GET("/conversations/:conversationId/scheduled", withAuth(messageHandler.GetScheduledMessages))
+	httpRouter.DELETE("/conversations/:conversationId/scheduled/:scheduledMessageId", withAuth(messageHandler.CancelScheduledMessage))
 	httpRouter.POST("/conversations/:conversationId/forwards", withAuth(messageHandler.ForwardMessage))
+	httpRouter.POST("/forwards", withAuth(messageHandler.ForwardMessages))
 	httpRouter.PUT("/messages/:messageId", withAuth(messageHandler.EditMessage))
 	httpRouter.DELETE("/messages/:messageId", withAuth(messageHandler.DeleteMessage))
+	httpRouter.GET("/messages/:messageId/revisions", withAuth(messageHandler.GetMessageRevisions))
+	httpRouter.GET("/messages/:messageId/replies", withAuth(messageHandler.GetReplies))
+	httpRouter.GET("/messages/:messageId/attachments/:index", withOptionalAuth(messageHandler.DownloadAttachment))
+	httpRouter.POST("/conversations/:conversationId/read", withAuth(messageHandler.MarkConversationRead))
+	httpRouter.GET("/search/messages", withAuth(messageHandler.SearchMessages))
+	httpRouter.GET("/me/starred", withAuth(messageHandler.GetStarredMessages))
+	httpRouter.POST("/me/starred", withAuth(messageHandler.StarMessage))
+	httpRouter.DELETE("/me/starred/:messageId", withAuth(messageHandler.UnstarMessage))
+
+	eventHandler := &handlers.EventHandler{Hub: router.events, MessageService: messageService}
+
+	httpRouter.GET("/events", withAuth(eventHandler.StreamEvents))
 
 	commentRepository := &repositories.CommentRepository{Database: router.database}
-	commentService := &services.CommentService{Repository: commentRepository}
+	commentService := &services.CommentService{Repository: commentRepository, Events: router.events}
 	commentHandler := &handlers.CommentHandler{Service: commentService}
 
 	httpRouter.POST("/messages/:messageId/comments", withAuth(commentHandler.CommentMessage))
+	httpRouter.PUT("/comments/:commentId", withAuth(commentHandler.UpdateComment))
 	httpRouter.DELETE("/comments/:commentId", withAuth(commentHandler.UncommentMessage))
 
+	uploadHandler := &handlers.UploadHandler{Store: router.store, Signer: router.signer, MessageService: messageService, TransferTimeout: router.transferTimeout}
+
+	httpRouter.GET("/uploads/*key", withOptionalAuth(uploadHandler.GetUpload))
+
 	return httpRouter
 }
 
+func (router *routerImpl) startDispatcher() {
+	messageRepository := &repositories.MessageRepository{Database: router.database}
+	messageService := &services.MessageService{Repository: messageRepository, Events: router.events, Store: router.store, Signer: router.signer, EditWindow: router.editWindow, ReactionDetailsMaxMembers: router.reactionDetailsMaxMembers}
+
+	router.dispatcherDone.Add(1)
+
+	go func() {
+		defer router.dispatcherDone.Done()
+
+		ticker := time.NewTicker(router.dispatchInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-router.dispatcherStop:
+				return
+			case <-ticker.C:
+				sent, err := messageService.DispatchScheduledMessages()
+				if err != nil {
+					router.logger.WithError(err).Error("failed to dispatch scheduled messages")
+				}
+
+				if sent > 0 {
+					router.logger.Debugf("dispatched %d scheduled messages", sent)
+				}
+			}
+		}
+	}()
+}
+
 func (router *routerImpl) Close() error {
+	router.closeOnce.Do(func() {
+		close(router.dispatcherStop)
+		router.dispatcherDone.Wait()
+
+		router.events.Close()
+	})
+
 	return nil
 }
@@ -1,17 +1,25 @@
 package services
 
 import (
+	"github.com/evaevangelisti/wasatext/service/api/events"
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/utils"
+	"github.com/evaevangelisti/wasatext/service/utils/emoji"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
 	"github.com/google/uuid"
 )
 
 type CommentService struct {
 	Repository *repositories.CommentRepository
+	Events     *events.Hub
 }
 
-func (service *CommentService) CreateComment(messageID, userID uuid.UUID, emoji string) (*models.Comment, error) {
+func (service *CommentService) CreateComment(messageID, userID uuid.UUID, reaction string) (*models.Comment, error) {
+	if !emoji.IsEmoji(reaction) {
+		return nil, errors.ErrBadRequest
+	}
+
 	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
 
 	conversation, err := conversationRepository.GetConversationByMessageID(messageID)
@@ -32,18 +40,33 @@ func (service *CommentService) CreateComment(messageID, userID uuid.UUID, emoji
 		return nil, errors.ErrForbidden
 	}
 
+	messageRepository := &repositories.MessageRepository{Database: service.Repository.Database}
+
+	message, err := messageRepository.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if message.Type == utils.MessageTypeSystem || !message.DeletedAt.IsZero() {
+		return nil, errors.ErrBadRequest
+	}
+
 	comments, err := service.Repository.GetCommentsByMessageID(messageID)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, comment := range comments {
-		if comment.Commenter.ID == userID {
+		if comment.Commenter.ID == userID && comment.Emoji == reaction {
 			return nil, errors.ErrConflict
 		}
 	}
 
-	commentID, err := service.Repository.CreateComment(messageID, userID, emoji)
+	commentID, err := service.Repository.CreateComment(messageID, userID, reaction)
 	if err != nil {
 		return nil, err
 	}
@@ -53,9 +76,83 @@ func (service *CommentService) CreateComment(messageID, userID uuid.UUID, emoji
 		return nil, err
 	}
 
+	publishToConversation(service.Events, service.Repository.Database, conversation.GetID(), events.CommentAdded, events.CommentAddedPayload{MessageID: messageID, Comment: *comment})
+
 	return comment, nil
 }
 
+func (service *CommentService) UpdateComment(commentID, userID uuid.UUID, reaction string) (*models.Comment, error) {
+	if !emoji.IsEmoji(reaction) {
+		return nil, errors.ErrBadRequest
+	}
+
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	conversation, err := conversationRepository.GetConversationByCommentID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	hasAccess, err := conversationRepository.IsUserInConversation(conversation.GetID(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasAccess {
+		return nil, errors.ErrForbidden
+	}
+
+	comment, err := service.Repository.GetCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if comment == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if comment.Commenter.ID != userID {
+		return nil, errors.ErrForbidden
+	}
+
+	if comment.Emoji == reaction {
+		return comment, nil
+	}
+
+	messageID, err := service.Repository.GetMessageIDByCommentID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := service.Repository.GetCommentsByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range comments {
+		if other.Commenter.ID == userID && other.Emoji == reaction {
+			return nil, errors.ErrConflict
+		}
+	}
+
+	if err := service.Repository.UpdateComment(commentID, reaction); err != nil {
+		return nil, err
+	}
+
+	updatedComment, err := service.Repository.GetCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversation.GetID(), events.CommentUpdated, events.CommentUpdatedPayload{MessageID: messageID, PreviousEmoji: comment.Emoji, Comment: *updatedComment})
+
+	return updatedComment, nil
+}
+
 func (service *CommentService) DeleteComment(commentID, userID uuid.UUID) error {
 	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
 
@@ -86,5 +183,17 @@ func (service *CommentService) DeleteComment(commentID, userID uuid.UUID) error
 		return errors.ErrForbidden
 	}
 
-	return service.Repository.DeleteComment(commentID)
+	messageID, err := service.Repository.GetMessageIDByCommentID(commentID)
+	if err != nil {
+		return err
+	}
+
+	err = service.Repository.DeleteComment(commentID)
+	if err != nil {
+		return err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversation.GetID(), events.CommentRemoved, events.CommentRemovedPayload{MessageID: messageID, CommentID: commentID, UserID: userID, Emoji: comment.Emoji})
+
+	return nil
 }
@@ -1,8 +1,17 @@
 package services
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	stdErrors "errors"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/events"
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/imaging"
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
 	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
 	"github.com/google/uuid"
@@ -10,10 +19,54 @@ import (
 
 type ConversationService struct {
 	Repository *repositories.ConversationRepository
+	Events     *events.Hub
+	Store      storage.BlobStore
+	Signer     *storage.URLSigner
+
+	ReactionDetailsMaxMembers int
+}
+
+func (service *ConversationService) GetConversationsByUserID(userID uuid.UUID, archived *bool) ([]models.Conversation, error) {
+	if err := markMessagesDelivered(service.Events, service.Repository.Database, userID, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	conversations, err := service.Repository.GetConversationsByUserID(userID, archived)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conversation := range conversations {
+		if err := service.setUnreadCount(conversation, userID); err != nil {
+			return nil, err
+		}
+
+		if err := applyConversationReactions(service.Repository.Database, conversation, userID, service.ReactionDetailsMaxMembers); err != nil {
+			return nil, err
+		}
+
+		signConversation(service.Signer, conversation)
+	}
+
+	return conversations, nil
 }
 
-func (service *ConversationService) GetConversationsByUserID(userID uuid.UUID) ([]models.Conversation, error) {
-	return service.Repository.GetConversationsByUserID(userID)
+func (service *ConversationService) setUnreadCount(conversation models.Conversation, userID uuid.UUID) error {
+	messageRepository := &repositories.MessageRepository{Database: service.Repository.Database}
+
+	unreadCount, err := messageRepository.CountUnreadMessages(conversation.GetID(), userID)
+	if err != nil {
+		return err
+	}
+
+	switch conv := conversation.(type) {
+	case *models.PrivateConversation:
+		conv.UnreadCount = unreadCount
+	case *models.GroupConversation:
+		conv.UnreadCount = unreadCount
+	}
+
+	return nil
 }
 
 func (service *ConversationService) GetConversationByID(conversationID, authenticatedUserID uuid.UUID) (models.Conversation, error) {
@@ -29,48 +82,71 @@ func (service *ConversationService) GetConversationByID(conversationID, authenti
 		return nil, errors.ErrNotFound
 	}
 
-	var messages []models.Message
+	hasAccess, err := service.Repository.IsUserInConversation(conversationID, authenticatedUserID)
+	if err != nil && !stdErrors.Is(err, errors.ErrNotFound) {
+		return nil, err
+	}
+
+	if !hasAccess {
+		return nil, errors.ErrForbidden
+	}
+
+	if err := markMessagesDelivered(service.Events, service.Repository.Database, authenticatedUserID, conversationID); err != nil {
+		return nil, err
+	}
+
+	messages, nextCursor, err := messageRepository.GetMessagesByConversationID(conversationID, authenticatedUserID, "", utils.MessagesPageDefaultLimit)
+	if err != nil {
+		return nil, err
+	}
 
 	switch conv := conversation.(type) {
 	case *models.PrivateConversation:
-		messages = conv.Messages
+		conv.Messages = messages
+		conv.NextCursor = nextCursor
 	case *models.GroupConversation:
-		messages = conv.Messages
+		conv.Messages = messages
+		conv.NextCursor = nextCursor
 	}
 
-	readAt := globaltime.Now()
-	var unreadMessageIDs []uuid.UUID
+	if err := service.setUnreadCount(conversation, authenticatedUserID); err != nil {
+		return nil, err
+	}
 
-	for _, message := range messages {
-		if _, alreadyRead := message.Trackings.Read[authenticatedUserID]; !alreadyRead && message.Sender.ID != authenticatedUserID {
-			unreadMessageIDs = append(unreadMessageIDs, message.ID)
-		}
+	pinRepository := &repositories.PinRepository{Database: service.Repository.Database}
+
+	pins, err := pinRepository.GetPinnedMessages(conversationID)
+	if err != nil {
+		return nil, err
 	}
 
-	for _, mid := range unreadMessageIDs {
-		err := messageRepository.AddMessageTracking(mid, authenticatedUserID, readAt)
-		if err != nil {
+	for i := range pins {
+		if err := applyMessageReactions(service.Repository.Database, conversationID, authenticatedUserID, &pins[i].Message, service.ReactionDetailsMaxMembers); err != nil {
 			return nil, err
 		}
 	}
 
-	for i := range messages {
-		if contains(unreadMessageIDs, messages[i].ID) {
-			messages[i].Trackings.Read[authenticatedUserID] = readAt
-		}
+	settings, err := service.Repository.GetConversationSettings(conversationID, authenticatedUserID)
+	if err != nil {
+		return nil, err
 	}
 
-	return conversation, nil
-}
+	switch conv := conversation.(type) {
+	case *models.PrivateConversation:
+		conv.Pins = pins
+		conv.Settings = settings
+	case *models.GroupConversation:
+		conv.Pins = pins
+		conv.Settings = settings
+	}
 
-func contains(ids []uuid.UUID, id uuid.UUID) bool {
-	for _, v := range ids {
-		if v == id {
-			return true
-		}
+	if err := applyConversationReactions(service.Repository.Database, conversation, authenticatedUserID, service.ReactionDetailsMaxMembers); err != nil {
+		return nil, err
 	}
 
-	return false
+	signConversation(service.Signer, conversation)
+
+	return conversation, nil
 }
 
 func (service *ConversationService) CreatePrivateConversation(participantIDs []uuid.UUID) (*models.PrivateConversation, error) {
@@ -83,6 +159,17 @@ func (service *ConversationService) CreatePrivateConversation(participantIDs []u
 		return existingConversation, errors.ErrConflict
 	}
 
+	blockRepository := &repositories.BlockRepository{Database: service.Repository.Database}
+
+	blocked, err := blockRepository.IsEitherUserBlocked(participantIDs[0], participantIDs[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if blocked {
+		return nil, errors.ErrForbidden
+	}
+
 	conversationID, err := service.Repository.CreatePrivateConversation(participantIDs)
 	if err != nil {
 		return nil, err
@@ -98,15 +185,23 @@ func (service *ConversationService) CreatePrivateConversation(participantIDs []u
 		return nil, errors.ErrInternal
 	}
 
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.ConversationCreated, privateConversation)
+
 	return privateConversation, nil
 }
 
-func (service *ConversationService) CreateGroupConversation(name string, memberIDs []uuid.UUID) (*models.GroupConversation, error) {
+func (service *ConversationService) CreateGroupConversation(name string, ownerID uuid.UUID, memberIDs []uuid.UUID) (*models.GroupConversation, error) {
 	if name == "" {
 		return nil, errors.ErrBadRequest
 	}
 
-	conversationID, err := service.Repository.CreateGroupConversation(name, memberIDs)
+	for _, memberID := range memberIDs {
+		if err := authorizeGroupAdd(service.Repository, ownerID, memberID); err != nil {
+			return nil, err
+		}
+	}
+
+	conversationID, err := service.Repository.CreateGroupConversation(name, ownerID, memberIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -121,6 +216,8 @@ func (service *ConversationService) CreateGroupConversation(name string, memberI
 		return nil, errors.ErrInternal
 	}
 
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.ConversationCreated, groupConversation)
+
 	return groupConversation, nil
 }
 
@@ -135,17 +232,20 @@ func (service *ConversationService) AddMember(conversationID, authenticatedUserI
 		return nil, errors.ErrBadRequest
 	}
 
-	if len(groupConversation.Members) >= 100 {
-		return nil, errors.ErrBadRequest
+	if err := authorizeGroupAction(service.Repository, conversationID, authenticatedUserID, groupConversation.Permissions.AddMembers); err != nil {
+		return nil, err
 	}
 
-	hasAccess, err := service.Repository.IsUserInConversation(conversationID, authenticatedUserID)
-	if err != nil {
+	if err := authorizeGroupAdd(service.Repository, authenticatedUserID, userID); err != nil {
 		return nil, err
 	}
 
-	if !hasAccess {
-		return nil, errors.ErrForbidden
+	return service.addMember(groupConversation, authenticatedUserID, userID)
+}
+
+func (service *ConversationService) addMember(groupConversation *models.GroupConversation, actorID, userID uuid.UUID) (*models.GroupConversation, error) {
+	if len(groupConversation.Members) >= utils.MaxGroupMembers {
+		return nil, errors.ErrBadRequest
 	}
 
 	for _, member := range groupConversation.Members {
@@ -154,12 +254,12 @@ func (service *ConversationService) AddMember(conversationID, authenticatedUserI
 		}
 	}
 
-	_, err = service.Repository.AddMember(conversationID, userID)
+	_, err := service.Repository.AddMember(groupConversation.ID, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	updatedConversation, err := service.Repository.GetConversationByID(conversationID)
+	updatedConversation, err := service.Repository.GetConversationByID(groupConversation.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -169,27 +269,160 @@ func (service *ConversationService) AddMember(conversationID, authenticatedUserI
 		return nil, errors.ErrInternal
 	}
 
+	for _, member := range updatedGroupConversation.Members {
+		if member.ID == userID {
+			publishToConversation(service.Events, service.Repository.Database, groupConversation.ID, events.MemberJoined, events.MemberJoinedPayload{Member: member})
+		}
+	}
+
+	if actorID == userID {
+		err = service.postSystemMessage(groupConversation.ID, userID, utils.SystemEventMemberJoined, uuid.Nil, "")
+	} else {
+		err = service.postSystemMessage(groupConversation.ID, actorID, utils.SystemEventMemberAdded, userID, "")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
 	return updatedGroupConversation, nil
 }
 
-func (service *ConversationService) UpdateGroupName(conversationID, authenticatedUserID uuid.UUID, name string) (*models.GroupConversation, error) {
+func (service *ConversationService) authorizeInvites(conversationID, userID uuid.UUID) error {
 	conversation, err := service.Repository.GetConversationByID(conversationID)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	_, ok := conversation.(*models.GroupConversation)
+	if conversation == nil {
+		return errors.ErrNotFound
+	}
+
+	groupConversation, ok := conversation.(*models.GroupConversation)
 	if !ok {
+		return errors.ErrBadRequest
+	}
+
+	return authorizeGroupAction(service.Repository, conversationID, userID, groupConversation.Permissions.AddMembers)
+}
+
+func (service *ConversationService) GetGroupInvites(conversationID, userID uuid.UUID) ([]models.GroupInvite, error) {
+	if err := service.authorizeInvites(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	inviteRepository := &repositories.InviteRepository{Database: service.Repository.Database}
+
+	return inviteRepository.GetActiveInvites(conversationID)
+}
+
+func (service *ConversationService) CreateGroupInvite(conversationID, userID uuid.UUID, maxUses int, expiresAt time.Time) (*models.GroupInvite, error) {
+	if err := service.authorizeInvites(conversationID, userID); err != nil {
+		return nil, err
+	}
+
+	now := globaltime.Now()
+
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(utils.GroupInviteDefaultTTL)
+	}
+
+	if !expiresAt.After(now) || expiresAt.After(now.Add(utils.GroupInviteMaxTTL)) {
 		return nil, errors.ErrBadRequest
 	}
 
-	hasAccess, err := service.Repository.IsUserInConversation(conversationID, authenticatedUserID)
+	tokenBytes := make([]byte, 32)
+
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	inviteRepository := &repositories.InviteRepository{Database: service.Repository.Database}
+
+	inviteID, err := inviteRepository.CreateInvite(conversationID, userID, token, maxUses, expiresAt)
 	if err != nil {
 		return nil, err
 	}
 
-	if !hasAccess {
-		return nil, errors.ErrForbidden
+	invite, err := inviteRepository.GetActiveInviteByID(conversationID, inviteID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invite == nil {
+		return nil, errors.ErrInternal
+	}
+
+	invite.Token = token
+
+	return invite, nil
+}
+
+func (service *ConversationService) RevokeGroupInvite(conversationID, userID, inviteID uuid.UUID) error {
+	if err := service.authorizeInvites(conversationID, userID); err != nil {
+		return err
+	}
+
+	inviteRepository := &repositories.InviteRepository{Database: service.Repository.Database}
+
+	invite, err := inviteRepository.GetActiveInviteByID(conversationID, inviteID)
+	if err != nil {
+		return err
+	}
+
+	if invite == nil {
+		return errors.ErrNotFound
+	}
+
+	return inviteRepository.RevokeInvite(inviteID)
+}
+
+func (service *ConversationService) JoinGroupByInvite(token string, userID uuid.UUID) (*models.GroupConversation, error) {
+	inviteRepository := &repositories.InviteRepository{Database: service.Repository.Database}
+
+	inviteID, conversationID, err := inviteRepository.UseInvite(token)
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversation, err := service.joinGroup(conversationID, userID)
+	if err != nil {
+		_ = inviteRepository.ReleaseInvite(inviteID)
+		return nil, err
+	}
+
+	return groupConversation, nil
+}
+
+func (service *ConversationService) joinGroup(conversationID, userID uuid.UUID) (*models.GroupConversation, error) {
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversation, ok := conversation.(*models.GroupConversation)
+	if !ok {
+		return nil, errors.ErrNotFound
+	}
+
+	return service.addMember(groupConversation, userID, userID)
+}
+
+func (service *ConversationService) UpdateGroupName(conversationID, authenticatedUserID uuid.UUID, name string) (*models.GroupConversation, error) {
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	groupConversation, ok := conversation.(*models.GroupConversation)
+	if !ok {
+		return nil, errors.ErrBadRequest
+	}
+
+	if err := authorizeGroupAction(service.Repository, conversationID, authenticatedUserID, groupConversation.Permissions.Rename); err != nil {
+		return nil, err
 	}
 
 	err = service.Repository.UpdateGroupName(conversationID, name)
@@ -207,31 +440,42 @@ func (service *ConversationService) UpdateGroupName(conversationID, authenticate
 		return nil, errors.ErrInternal
 	}
 
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.ConversationUpdated, updatedGroupConversation)
+
+	if err := service.postSystemMessage(conversationID, authenticatedUserID, utils.SystemEventGroupRenamed, uuid.Nil, name); err != nil {
+		return nil, err
+	}
+
 	return updatedGroupConversation, nil
 }
 
-func (service *ConversationService) UpdateGroupPhoto(conversationID, authenticatedUserID uuid.UUID, photo string) (*models.GroupConversation, error) {
+func (service *ConversationService) UpdateGroupPhoto(conversationID, authenticatedUserID uuid.UUID, image *storage.Upload) (*models.GroupConversation, error) {
 	conversation, err := service.Repository.GetConversationByID(conversationID)
 	if err != nil {
 		return nil, err
 	}
 
-	_, ok := conversation.(*models.GroupConversation)
+	groupConversation, ok := conversation.(*models.GroupConversation)
 	if !ok {
 		return nil, errors.ErrBadRequest
 	}
 
-	hasAccess, err := service.Repository.IsUserInConversation(conversationID, authenticatedUserID)
-	if err != nil {
+	if err := authorizeGroupAction(service.Repository, conversationID, authenticatedUserID, groupConversation.Permissions.ChangePhoto); err != nil {
 		return nil, err
 	}
 
-	if !hasAccess {
-		return nil, errors.ErrForbidden
+	photo, photoThumbnail, err := storeImage(service.Store, storage.GroupPhotosDir, image, imaging.Avatar)
+	if err != nil {
+		return nil, err
 	}
 
-	err = service.Repository.UpdateGroupPhoto(conversationID, photo)
+	oldPhotos, err := service.Repository.UpdateGroupPhoto(conversationID, photo, photoThumbnail)
 	if err != nil {
+		_ = removeUploads(service.Store, photo, photoThumbnail)
+		return nil, err
+	}
+
+	if err := removeUploads(service.Store, oldPhotos...); err != nil {
 		return nil, err
 	}
 
@@ -245,6 +489,12 @@ func (service *ConversationService) UpdateGroupPhoto(conversationID, authenticat
 		return nil, errors.ErrInternal
 	}
 
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.ConversationUpdated, updatedGroupConversation)
+
+	if err := service.postSystemMessage(conversationID, authenticatedUserID, utils.SystemEventPhotoChanged, uuid.Nil, ""); err != nil {
+		return nil, err
+	}
+
 	return updatedGroupConversation, nil
 }
 
@@ -268,22 +518,438 @@ func (service *ConversationService) RemoveMember(conversationID, userID uuid.UUI
 		return errors.ErrForbidden
 	}
 
+	if err := service.handOverOwnership(conversationID, userID); err != nil {
+		return err
+	}
+
 	err = service.Repository.RemoveMember(conversationID, userID)
 	if err != nil {
 		return err
 	}
 
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.MemberLeft, events.MemberLeftPayload{UserID: userID}, userID)
+
 	members, err := service.Repository.GetMembers(conversationID)
 	if err != nil {
 		return err
 	}
 
 	if len(members) == 0 {
-		err = service.Repository.DeleteGroupConversation(conversationID)
+		uploads, err := service.Repository.DeleteGroupConversation(conversationID)
 		if err != nil {
 			return err
 		}
+
+		return removeUploads(service.Store, uploads...)
+	}
+
+	return service.postSystemMessage(conversationID, userID, utils.SystemEventMemberLeft, uuid.Nil, "")
+}
+
+func (service *ConversationService) handOverOwnership(conversationID, ownerID uuid.UUID) error {
+	role, err := service.Repository.GetMemberRole(conversationID, ownerID)
+	if err != nil {
+		return err
+	}
+
+	if role != utils.RoleOwner {
+		return nil
+	}
+
+	members, err := service.Repository.GetMembers(conversationID)
+	if err != nil {
+		return err
+	}
+
+	var successorID uuid.UUID
+
+	for _, member := range members {
+		if member.ID == ownerID {
+			continue
+		}
+
+		if member.Role == utils.RoleAdmin {
+			successorID = member.ID
+			break
+		}
+
+		if successorID == uuid.Nil {
+			successorID = member.ID
+		}
+	}
+
+	if successorID == uuid.Nil {
+		return nil
+	}
+
+	return service.Repository.TransferOwnership(conversationID, ownerID, successorID)
+}
+
+func (service *ConversationService) KickMember(conversationID, authenticatedUserID, userID uuid.UUID) error {
+	if authenticatedUserID == userID {
+		return service.RemoveMember(conversationID, userID)
+	}
+
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return err
+	}
+
+	if conversation == nil {
+		return errors.ErrNotFound
+	}
+
+	if _, ok := conversation.(*models.GroupConversation); !ok {
+		return errors.ErrBadRequest
+	}
+
+	actorRole, err := service.Repository.GetMemberRole(conversationID, authenticatedUserID)
+	if err != nil {
+		return err
+	}
+
+	if !isGroupAdmin(actorRole) {
+		return errors.ErrForbidden
+	}
+
+	targetRole, err := service.Repository.GetMemberRole(conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if targetRole == "" {
+		return errors.ErrNotFound
+	}
+
+	if targetRole == utils.RoleOwner || (targetRole == utils.RoleAdmin && actorRole != utils.RoleOwner) {
+		return errors.ErrForbidden
+	}
+
+	err = service.Repository.RemoveMember(conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.MemberLeft, events.MemberLeftPayload{UserID: userID}, userID)
+
+	return service.postSystemMessage(conversationID, authenticatedUserID, utils.SystemEventMemberRemoved, userID, "")
+}
+
+func (service *ConversationService) UpdateMemberRole(conversationID, authenticatedUserID, userID uuid.UUID, role string) (*models.GroupConversation, error) {
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if _, ok := conversation.(*models.GroupConversation); !ok {
+		return nil, errors.ErrBadRequest
+	}
+
+	actorRole, err := service.Repository.GetMemberRole(conversationID, authenticatedUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isGroupAdmin(actorRole) {
+		return nil, errors.ErrForbidden
+	}
+
+	if authenticatedUserID == userID {
+		return nil, errors.ErrBadRequest
+	}
+
+	targetRole, err := service.Repository.GetMemberRole(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if targetRole == "" {
+		return nil, errors.ErrNotFound
+	}
+
+	if targetRole == utils.RoleOwner {
+		return nil, errors.ErrForbidden
+	}
+
+	switch role {
+	case utils.RoleOwner:
+		if actorRole != utils.RoleOwner {
+			return nil, errors.ErrForbidden
+		}
+
+		err = service.Repository.TransferOwnership(conversationID, authenticatedUserID, userID)
+
+	case utils.RoleAdmin:
+		err = service.Repository.UpdateMemberRole(conversationID, userID, role)
+
+	case utils.RoleMember:
+		if targetRole == utils.RoleAdmin && actorRole != utils.RoleOwner {
+			return nil, errors.ErrForbidden
+		}
+
+		err = service.Repository.UpdateMemberRole(conversationID, userID, role)
+
+	default:
+		return nil, errors.ErrBadRequest
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return service.publishGroupUpdate(conversationID)
+}
+
+func (service *ConversationService) UpdateGroupPermissions(conversationID, authenticatedUserID uuid.UUID, permissions models.GroupPermissions) (*models.GroupConversation, error) {
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if _, ok := conversation.(*models.GroupConversation); !ok {
+		return nil, errors.ErrBadRequest
+	}
+
+	if err := authorizeGroupAction(service.Repository, conversationID, authenticatedUserID, utils.PermissionAdmins); err != nil {
+		return nil, err
+	}
+
+	err = service.Repository.UpdateGroupPermissions(conversationID, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.publishGroupUpdate(conversationID)
+}
+
+func (service *ConversationService) authorizePin(conversationID, authenticatedUserID, messageID uuid.UUID) error {
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return err
+	}
+
+	if conversation == nil {
+		return errors.ErrNotFound
+	}
+
+	hasAccess, err := service.Repository.IsUserInConversation(conversationID, authenticatedUserID)
+	if err != nil {
+		return err
+	}
+
+	if !hasAccess {
+		return errors.ErrForbidden
+	}
+
+	if groupConversation, ok := conversation.(*models.GroupConversation); ok {
+		if err := authorizeGroupAction(service.Repository, conversationID, authenticatedUserID, groupConversation.Permissions.Pin); err != nil {
+			return err
+		}
+	}
+
+	messageConversation, err := service.Repository.GetConversationByMessageID(messageID)
+	if err != nil {
+		return err
+	}
+
+	if messageConversation == nil || messageConversation.GetID() != conversationID {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (service *ConversationService) PinMessage(conversationID, authenticatedUserID, messageID uuid.UUID) (*models.PinnedMessage, error) {
+	if err := service.authorizePin(conversationID, authenticatedUserID, messageID); err != nil {
+		return nil, err
+	}
+
+	messageRepository := &repositories.MessageRepository{Database: service.Repository.Database}
+
+	message, err := messageRepository.GetMessageByID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	if message.Type == utils.MessageTypeSystem || !message.DeletedAt.IsZero() {
+		return nil, errors.ErrBadRequest
+	}
+
+	pinRepository := &repositories.PinRepository{Database: service.Repository.Database}
+
+	pinned, err := pinRepository.IsMessagePinned(conversationID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pinned {
+		return nil, errors.ErrConflict
+	}
+
+	count, err := pinRepository.CountPinnedMessages(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= utils.MaxPinnedMessages {
+		return nil, errors.ErrBadRequest
+	}
+
+	if err := pinRepository.PinMessage(conversationID, messageID, authenticatedUserID); err != nil {
+		return nil, err
+	}
+
+	pins, err := pinRepository.GetPinnedMessages(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pin := range pins {
+		if pin.Message.ID != messageID {
+			continue
+		}
+
+		if err := applyMessageReactions(service.Repository.Database, conversationID, uuid.Nil, &pin.Message, service.ReactionDetailsMaxMembers); err != nil {
+			return nil, err
+		}
+
+		signMessage(service.Signer, &pin.Message)
+
+		publishToConversation(service.Events, service.Repository.Database, conversationID, events.MessagePinned, pin)
+
+		return &pin, nil
+	}
+
+	return nil, errors.ErrInternal
+}
+
+func (service *ConversationService) UnpinMessage(conversationID, authenticatedUserID, messageID uuid.UUID) error {
+	if err := service.authorizePin(conversationID, authenticatedUserID, messageID); err != nil {
+		return err
+	}
+
+	pinRepository := &repositories.PinRepository{Database: service.Repository.Database}
+
+	pinned, err := pinRepository.IsMessagePinned(conversationID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if !pinned {
+		return errors.ErrNotFound
 	}
 
+	if err := pinRepository.UnpinMessage(conversationID, messageID); err != nil {
+		return err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.MessageUnpinned, events.MessageUnpinnedPayload{MessageID: messageID})
+
 	return nil
 }
+
+func (service *ConversationService) UpdateConversationSettings(conversationID, userID uuid.UUID, update models.ConversationSettingsUpdate) (*models.ConversationSettings, error) {
+	conversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	hasAccess, err := service.Repository.IsUserInConversation(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasAccess {
+		return nil, errors.ErrForbidden
+	}
+
+	currentSettings, err := service.Repository.GetConversationSettings(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := *currentSettings
+
+	if update.MutedUntil != nil {
+		settings.MutedUntil = update.MutedUntil.Truncate(time.Second)
+	}
+
+	if update.Archived != nil {
+		settings.Archived = *update.Archived
+	}
+
+	if update.Pinned != nil {
+		settings.Pinned = *update.Pinned
+	}
+
+	if settings.Pinned && !currentSettings.Pinned {
+		count, err := service.Repository.CountPinnedConversations(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if count >= utils.MaxPinnedConversations {
+			return nil, errors.ErrBadRequest
+		}
+	}
+
+	if err := service.Repository.UpdateConversationSettings(conversationID, userID, settings); err != nil {
+		return nil, err
+	}
+
+	if service.Events != nil {
+		service.Events.Publish([]uuid.UUID{userID}, events.ConversationSettingsUpdated, conversationID, settings)
+	}
+
+	return &settings, nil
+}
+
+func (service *ConversationService) postSystemMessage(conversationID, actorID uuid.UUID, event string, targetID uuid.UUID, content string) error {
+	messageRepository := &repositories.MessageRepository{Database: service.Repository.Database}
+
+	messageID, err := messageRepository.CreateSystemMessage(conversationID, actorID, event, targetID, content)
+	if err != nil {
+		return err
+	}
+
+	message, err := messageRepository.GetMessageByID(messageID)
+	if err != nil {
+		return err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.MessageSent, message)
+
+	return nil
+}
+
+func (service *ConversationService) publishGroupUpdate(conversationID uuid.UUID) (*models.GroupConversation, error) {
+	updatedConversation, err := service.Repository.GetConversationByID(conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedGroupConversation, ok := updatedConversation.(*models.GroupConversation)
+	if !ok {
+		return nil, errors.ErrInternal
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.ConversationUpdated, updatedGroupConversation)
+
+	return updatedGroupConversation, nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"github.com/evaevangelisti/wasatext/service/api/events"
+	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/google/uuid"
+)
+
+func publishToConversation(hub *events.Hub, database database.Database, conversationID uuid.UUID, eventType events.Type, payload interface{}, extraRecipientIDs ...uuid.UUID) {
+	if hub == nil {
+		return
+	}
+
+	conversationRepository := &repositories.ConversationRepository{Database: database}
+
+	recipientIDs, err := conversationRepository.GetUserIDsByConversationID(conversationID)
+	if err != nil {
+		return
+	}
+
+	hub.Publish(append(recipientIDs, extraRecipientIDs...), eventType, conversationID, payload)
+}
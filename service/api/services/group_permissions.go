@@ -0,0 +1,110 @@
+package services
+
+import (
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/utils"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/google/uuid"
+)
+
+func isGroupAdmin(role string) bool {
+	return role == utils.RoleOwner || role == utils.RoleAdmin
+}
+
+func authorizeGroupAction(conversationRepository *repositories.ConversationRepository, conversationID, userID uuid.UUID, permission string) error {
+	role, err := conversationRepository.GetMemberRole(conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		return errors.ErrForbidden
+	}
+
+	if permission == utils.PermissionAdmins && !isGroupAdmin(role) {
+		return errors.ErrForbidden
+	}
+
+	return nil
+}
+
+func authorizePost(conversationRepository *repositories.ConversationRepository, conversationID, userID uuid.UUID) error {
+	conversation, err := conversationRepository.GetConversationByID(conversationID)
+	if err != nil {
+		return err
+	}
+
+	switch conv := conversation.(type) {
+	case *models.PrivateConversation:
+		return authorizePrivateMessage(conversationRepository, conv, userID)
+	case *models.GroupConversation:
+		return authorizeGroupAction(conversationRepository, conversationID, userID, conv.Permissions.Post)
+	}
+
+	return nil
+}
+
+func authorizePrivateMessage(conversationRepository *repositories.ConversationRepository, conversation *models.PrivateConversation, userID uuid.UUID) error {
+	blockRepository := &repositories.BlockRepository{Database: conversationRepository.Database}
+
+	for _, participant := range conversation.Participants {
+		if participant.ID == userID {
+			continue
+		}
+
+		blocked, err := blockRepository.IsEitherUserBlocked(userID, participant.ID)
+		if err != nil {
+			return err
+		}
+
+		if blocked {
+			return errors.ErrForbidden
+		}
+	}
+
+	return nil
+}
+
+func authorizeGroupAdd(conversationRepository *repositories.ConversationRepository, actorID, userID uuid.UUID) error {
+	if actorID == userID {
+		return nil
+	}
+
+	blockRepository := &repositories.BlockRepository{Database: conversationRepository.Database}
+	userRepository := &repositories.UserRepository{Database: conversationRepository.Database}
+
+	blocked, err := blockRepository.IsEitherUserBlocked(actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	if blocked {
+		return errors.ErrForbidden
+	}
+
+	privacy, err := userRepository.GetPrivacySettings(userID)
+	if err != nil {
+		return err
+	}
+
+	if privacy == nil {
+		return errors.ErrNotFound
+	}
+
+	switch privacy.GroupAdd {
+	case utils.GroupAddNobody:
+		return errors.ErrForbidden
+	case utils.GroupAddContacts:
+		contact, err := conversationRepository.GetPrivateConversationByParticipants([]uuid.UUID{actorID, userID})
+		if err != nil {
+			return err
+		}
+
+		if contact == nil {
+			return errors.ErrForbidden
+		}
+	}
+
+	return nil
+}
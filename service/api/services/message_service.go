@@ -1,17 +1,31 @@
 package services
 
 import (
+	stdErrors "errors"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/events"
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/media"
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
 	"github.com/google/uuid"
 )
 
 type MessageService struct {
 	Repository *repositories.MessageRepository
+	Events     *events.Hub
+	Store      storage.BlobStore
+	Signer     *storage.URLSigner
+	EditWindow time.Duration
+
+	ReactionDetailsMaxMembers int
 }
 
-func (service *MessageService) CreateMessage(conversationID, userID uuid.UUID, content, attachment string, replyToMessageID uuid.UUID) (*models.Message, error) {
+func (service *MessageService) GetMessagesByConversationID(conversationID, userID uuid.UUID, before string, limit int) (*models.MessagePage, error) {
 	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
 
 	hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
@@ -23,39 +37,262 @@ func (service *MessageService) CreateMessage(conversationID, userID uuid.UUID, c
 		return nil, errors.ErrForbidden
 	}
 
-	if content == "" && attachment == "" {
-		return nil, errors.ErrBadRequest
+	messages, nextCursor, err := service.Repository.GetMessagesByConversationID(conversationID, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyReactions(service.Repository.Database, conversationID, userID, messages, service.ReactionDetailsMaxMembers); err != nil {
+		return nil, err
+	}
+
+	signMessages(service.Signer, messages)
+
+	return &models.MessagePage{Messages: messages, NextCursor: nextCursor}, nil
+}
+
+func (service *MessageService) GetRepliesByMessageID(messageID, userID uuid.UUID, before string, limit int) (*models.MessagePage, error) {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	conversation, err := conversationRepository.GetConversationByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	hasAccess, err := conversationRepository.IsUserInConversation(conversation.GetID(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasAccess {
+		return nil, errors.ErrForbidden
+	}
+
+	messages, nextCursor, err := service.Repository.GetRepliesByMessageID(messageID, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyReactions(service.Repository.Database, conversation.GetID(), userID, messages, service.ReactionDetailsMaxMembers); err != nil {
+		return nil, err
+	}
+
+	signMessages(service.Signer, messages)
+
+	return &models.MessagePage{Messages: messages, NextCursor: nextCursor}, nil
+}
+
+func (service *MessageService) MarkMessagesRead(conversationID, userID, messageID uuid.UUID) error {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !hasAccess {
+		return errors.ErrForbidden
+	}
+
+	readAt := globaltime.Now()
+
+	messageIDs, err := service.Repository.MarkMessagesRead(conversationID, userID, messageID, readAt)
+	if err != nil {
+		return err
 	}
 
-	if replyToMessageID != uuid.Nil {
-		replyMessage, err := service.Repository.GetMessageByID(replyToMessageID)
+	if len(messageIDs) > 0 {
+		publishToConversation(service.Events, service.Repository.Database, conversationID, events.MessagesRead, events.MessagesReadPayload{
+			UserID:     userID,
+			MessageIDs: messageIDs,
+			ReadAt:     readAt,
+		})
+	}
+
+	return nil
+}
+
+func (service *MessageService) MarkMessagesDelivered(conversationID, userID uuid.UUID) error {
+	return markMessagesDelivered(service.Events, service.Repository.Database, userID, conversationID)
+}
 
+func (service *MessageService) GetAttachment(messageID uuid.UUID, index int, userID uuid.UUID, expires, signature string) (*models.Attachment, *storage.Blob, error) {
+	message, err := service.Repository.GetMessageByID(messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if message == nil || index < 0 || index >= len(message.Attachments) {
+		return nil, nil, errors.ErrNotFound
+	}
+
+	attachment := message.Attachments[index]
+
+	key, ok := storage.KeyFromURL(attachment.URL)
+	if !ok {
+		return nil, nil, errors.ErrNotFound
+	}
+
+	if signature != "" {
+		if err := service.Signer.Verify(key, expires, signature); err != nil {
+			return nil, nil, errors.ErrForbidden
+		}
+	} else {
+		if userID == uuid.Nil {
+			return nil, nil, errors.ErrUnauthorized
+		}
+
+		conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+		conversation, err := conversationRepository.GetConversationByMessageID(messageID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		if replyMessage == nil || replyMessage.Sender.ID == uuid.Nil {
-			return nil, errors.ErrBadRequest
+		if conversation == nil {
+			return nil, nil, errors.ErrNotFound
+		}
+
+		hasAccess, err := conversationRepository.IsUserInConversation(conversation.GetID(), userID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !hasAccess {
+			return nil, nil, errors.ErrForbidden
 		}
 	}
 
-	messageID, err := service.Repository.CreateMessage(conversationID, userID, content, attachment, replyToMessageID)
+	blob, err := service.Store.Get(key)
 	if err != nil {
-		return nil, err
+		if stdErrors.Is(err, storage.ErrNotFound) {
+			return nil, nil, errors.ErrNotFound
+		}
+
+		return nil, nil, errors.ErrInternal
 	}
 
-	message, err := service.Repository.GetMessageByID(messageID)
+	return &attachment, blob, nil
+}
+
+func (service *MessageService) AuthorizeAttachment(attachment string, userID uuid.UUID) error {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	attachmentRepository := &repositories.AttachmentRepository{Database: service.Repository.Database}
+
+	conversationIDs, err := attachmentRepository.GetConversationIDsByURL(attachment)
+	if err != nil {
+		return err
+	}
+
+	if len(conversationIDs) == 0 {
+		return errors.ErrNotFound
+	}
+
+	for _, conversationID := range conversationIDs {
+		hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
+		if err != nil && err != errors.ErrNotFound {
+			return err
+		}
+
+		if hasAccess {
+			return nil
+		}
+	}
+
+	return errors.ErrForbidden
+}
+
+func (service *MessageService) SearchMessages(userID uuid.UUID, q string, conversationID, senderID uuid.UUID, before string, limit int) (*models.MessageSearchPage, error) {
+	if conversationID != uuid.Nil {
+		conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+		hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasAccess {
+			return nil, errors.ErrForbidden
+		}
+	}
+
+	results, nextCursor, err := service.Repository.SearchMessages(userID, q, conversationID, senderID, before, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return message, nil
+	for i := range results {
+		if err := applyMessageReactions(service.Repository.Database, results[i].ConversationID, userID, &results[i].Message, service.ReactionDetailsMaxMembers); err != nil {
+			return nil, err
+		}
+
+		signMessage(service.Signer, &results[i].Message)
+	}
+
+	return &models.MessageSearchPage{Results: results, NextCursor: nextCursor}, nil
 }
 
-func (service *MessageService) CreateForwardedMessage(conversationID, userID, originalMessageID uuid.UUID) (*models.Message, error) {
+func (service *MessageService) GetStarredMessages(userID uuid.UUID, before string, limit int) (*models.StarredMessagePage, error) {
 	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+	starRepository := &repositories.StarRepository{Database: service.Repository.Database}
 
-	hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
+	starred := []models.StarredMessage{}
+	nextCursor := before
+
+	for {
+		stars, cursor, err := starRepository.GetStarredMessages(userID, nextCursor, limit-len(starred))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, star := range stars {
+			hasAccess, err := conversationRepository.IsUserInConversation(star.ConversationID, userID)
+			if err != nil && !stdErrors.Is(err, errors.ErrNotFound) {
+				return nil, err
+			}
+
+			if !hasAccess {
+				continue
+			}
+
+			if err := applyMessageReactions(service.Repository.Database, star.ConversationID, userID, &star.Message, service.ReactionDetailsMaxMembers); err != nil {
+				return nil, err
+			}
+
+			signMessage(service.Signer, &star.Message)
+
+			starred = append(starred, star)
+		}
+
+		nextCursor = cursor
+
+		if nextCursor == "" || len(starred) == limit {
+			break
+		}
+	}
+
+	return &models.StarredMessagePage{Starred: starred, NextCursor: nextCursor}, nil
+}
+
+func (service *MessageService) StarMessage(messageID, userID uuid.UUID) (*models.StarredMessage, error) {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+	starRepository := &repositories.StarRepository{Database: service.Repository.Database}
+
+	conversation, err := conversationRepository.GetConversationByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	hasAccess, err := conversationRepository.IsUserInConversation(conversation.GetID(), userID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,26 +301,123 @@ func (service *MessageService) CreateForwardedMessage(conversationID, userID, or
 		return nil, errors.ErrForbidden
 	}
 
-	originalConversation, err := conversationRepository.GetConversationByMessageID(originalMessageID)
+	message, err := service.Repository.GetMessageByID(messageID)
 	if err != nil {
 		return nil, err
 	}
 
-	if originalConversation == nil {
+	if message == nil {
 		return nil, errors.ErrNotFound
 	}
 
-	hasAccess, err = conversationRepository.IsUserInConversation(originalConversation.GetID(), userID)
+	if message.Type == utils.MessageTypeSystem || !message.DeletedAt.IsZero() {
+		return nil, errors.ErrBadRequest
+	}
+
+	starred, err := starRepository.IsMessageStarred(userID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if starred {
+		return nil, errors.ErrConflict
+	}
+
+	starredAt, err := starRepository.StarMessage(userID, messageID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := applyMessageReactions(service.Repository.Database, conversation.GetID(), userID, message, service.ReactionDetailsMaxMembers); err != nil {
+		return nil, err
+	}
+
+	signMessage(service.Signer, message)
+
+	return &models.StarredMessage{
+		ConversationID: conversation.GetID(),
+		Message:        *message,
+		StarredAt:      starredAt,
+	}, nil
+}
+
+func (service *MessageService) UnstarMessage(messageID, userID uuid.UUID) error {
+	starRepository := &repositories.StarRepository{Database: service.Repository.Database}
+
+	starred, err := starRepository.IsMessageStarred(userID, messageID)
+	if err != nil {
+		return err
+	}
+
+	if !starred {
+		return errors.ErrNotFound
+	}
+
+	return starRepository.UnstarMessage(userID, messageID)
+}
+
+func (service *MessageService) authorizeSend(conversationID, userID, replyToMessageID uuid.UUID) error {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
+	if err != nil {
+		return err
+	}
+
 	if !hasAccess {
-		return nil, errors.ErrForbidden
+		return errors.ErrForbidden
 	}
 
-	messageID, err := service.Repository.CreateForwardedMessage(conversationID, userID, originalMessageID)
+	if err := authorizePost(conversationRepository, conversationID, userID); err != nil {
+		return err
+	}
+
+	if replyToMessageID == uuid.Nil {
+		return nil
+	}
+
+	replyMessage, err := service.Repository.GetMessageByID(replyToMessageID)
 	if err != nil {
+		return err
+	}
+
+	if replyMessage == nil || replyMessage.Sender.ID == uuid.Nil || replyMessage.Type == utils.MessageTypeSystem || !replyMessage.DeletedAt.IsZero() {
+		return errors.ErrBadRequest
+	}
+
+	replyConversation, err := conversationRepository.GetConversationByMessageID(replyToMessageID)
+	if err != nil {
+		return err
+	}
+
+	if replyConversation == nil || replyConversation.GetID() != conversationID {
+		return errors.ErrBadRequest
+	}
+
+	return nil
+}
+
+func (service *MessageService) CreateMessage(conversationID, userID uuid.UUID, content string, files []*media.File, replyToMessageID uuid.UUID) (*models.Message, error) {
+	if err := service.authorizeSend(conversationID, userID, replyToMessageID); err != nil {
+		return nil, err
+	}
+
+	if content == "" && len(files) == 0 {
+		return nil, errors.ErrBadRequest
+	}
+
+	if len(files) > utils.MaxMessageAttachments {
+		return nil, errors.ErrBadRequest
+	}
+
+	attachments, err := storeAttachments(service.Store, files)
+	if err != nil {
+		return nil, err
+	}
+
+	messageID, err := service.Repository.CreateMessage(conversationID, userID, content, attachments, replyToMessageID)
+	if err != nil {
+		_ = removeAttachments(service.Store, attachments)
 		return nil, err
 	}
 
@@ -92,9 +426,253 @@ func (service *MessageService) CreateForwardedMessage(conversationID, userID, or
 		return nil, err
 	}
 
+	signMessage(service.Signer, message)
+
+	publishToConversation(service.Events, service.Repository.Database, conversationID, events.MessageSent, message)
+
 	return message, nil
 }
 
+func (service *MessageService) GetScheduledMessages(conversationID, userID uuid.UUID) ([]models.ScheduledMessage, error) {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	hasAccess, accessErr := conversationRepository.IsUserInConversation(conversationID, userID)
+	if accessErr != nil && !stdErrors.Is(accessErr, errors.ErrNotFound) {
+		return nil, accessErr
+	}
+
+	scheduledMessageRepository := &repositories.ScheduledMessageRepository{Database: service.Repository.Database}
+
+	scheduledMessages, err := scheduledMessageRepository.GetScheduledMessages(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasAccess && len(scheduledMessages) == 0 {
+		if accessErr != nil {
+			return nil, accessErr
+		}
+
+		return nil, errors.ErrForbidden
+	}
+
+	return scheduledMessages, nil
+}
+
+func (service *MessageService) ScheduleMessage(conversationID, userID uuid.UUID, content string, replyToMessageID uuid.UUID, sendAt time.Time) (*models.ScheduledMessage, error) {
+	if err := service.authorizeSend(conversationID, userID, replyToMessageID); err != nil {
+		return nil, err
+	}
+
+	if content == "" {
+		return nil, errors.ErrBadRequest
+	}
+
+	now := globaltime.Now()
+
+	if !sendAt.After(now) || sendAt.After(now.Add(utils.ScheduledMessageMaxDelay)) {
+		return nil, errors.ErrBadRequest
+	}
+
+	scheduledMessageRepository := &repositories.ScheduledMessageRepository{Database: service.Repository.Database}
+
+	count, err := scheduledMessageRepository.CountScheduledMessages(conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= utils.MaxScheduledMessages {
+		return nil, errors.ErrBadRequest
+	}
+
+	return scheduledMessageRepository.CreateScheduledMessage(conversationID, userID, content, replyToMessageID, sendAt)
+}
+
+func (service *MessageService) CancelScheduledMessage(conversationID, userID, scheduledMessageID uuid.UUID) error {
+	scheduledMessageRepository := &repositories.ScheduledMessageRepository{Database: service.Repository.Database}
+
+	cancelled, err := scheduledMessageRepository.CancelScheduledMessage(conversationID, userID, scheduledMessageID)
+	if err != nil {
+		return err
+	}
+
+	if !cancelled {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+func (service *MessageService) DispatchScheduledMessages() (int, error) {
+	scheduledMessageRepository := &repositories.ScheduledMessageRepository{Database: service.Repository.Database}
+
+	due, err := scheduledMessageRepository.GetDueScheduledMessages(globaltime.Now(), utils.ScheduledMessagesBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		sent          int
+		dispatchError error
+	)
+
+	for _, scheduledMessage := range due {
+		claimed, err := scheduledMessageRepository.ClaimScheduledMessage(scheduledMessage.ID, globaltime.Now(), utils.ScheduledMessageLease)
+		if err != nil {
+			return sent, err
+		}
+
+		if !claimed {
+			continue
+		}
+
+		_, err = service.CreateMessage(scheduledMessage.ConversationID, scheduledMessage.SenderID, scheduledMessage.Content, nil, scheduledMessage.ReplyToMessageID)
+
+		var sendError *errors.Error
+		if err != nil && (!stdErrors.As(err, &sendError) || sendError.StatusCode >= errors.ErrInternal.StatusCode) {
+			if dispatchError == nil {
+				dispatchError = err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			if err := service.failScheduledMessage(scheduledMessageRepository, scheduledMessage, sendError.Message); err != nil {
+				return sent, err
+			}
+
+			continue
+		}
+
+		if _, err := scheduledMessageRepository.CompleteScheduledMessage(scheduledMessage.ID); err != nil {
+			return sent, err
+		}
+
+		sent++
+	}
+
+	return sent, dispatchError
+}
+
+func (service *MessageService) failScheduledMessage(scheduledMessageRepository *repositories.ScheduledMessageRepository, scheduledMessage models.ScheduledMessage, reason string) error {
+	failedAt := globaltime.Now().UTC().Truncate(time.Second)
+
+	failed, err := scheduledMessageRepository.FailScheduledMessage(scheduledMessage.ID, failedAt, reason)
+	if err != nil || !failed {
+		return err
+	}
+
+	scheduledMessage.Status = utils.ScheduledMessageStatusFailed
+	scheduledMessage.FailedAt = &failedAt
+	scheduledMessage.FailureReason = reason
+
+	if service.Events != nil {
+		service.Events.Publish([]uuid.UUID{scheduledMessage.SenderID}, events.ScheduledMessageFailed, scheduledMessage.ConversationID, scheduledMessage)
+	}
+
+	return nil
+}
+
+func (service *MessageService) CreateForwardedMessage(conversationID, userID, originalMessageID uuid.UUID) (*models.Message, error) {
+	messages, err := service.CreateForwardedMessages(userID, []uuid.UUID{originalMessageID}, []uuid.UUID{conversationID})
+	if err != nil {
+		return nil, err
+	}
+
+	return &messages[0], nil
+}
+
+func (service *MessageService) CreateForwardedMessages(userID uuid.UUID, originalMessageIDs, conversationIDs []uuid.UUID) ([]models.Message, error) {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	if len(originalMessageIDs) == 0 || len(originalMessageIDs) > utils.MaxForwardedMessages {
+		return nil, errors.ErrBadRequest
+	}
+
+	if len(conversationIDs) == 0 || len(conversationIDs) > utils.MaxForwardConversations {
+		return nil, errors.ErrBadRequest
+	}
+
+	for _, conversationID := range conversationIDs {
+		hasAccess, err := conversationRepository.IsUserInConversation(conversationID, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasAccess {
+			return nil, errors.ErrForbidden
+		}
+
+		if err := authorizePost(conversationRepository, conversationID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	originalMessages := make([]models.Message, 0, len(originalMessageIDs))
+
+	for _, originalMessageID := range originalMessageIDs {
+		originalConversation, err := conversationRepository.GetConversationByMessageID(originalMessageID)
+		if err != nil {
+			return nil, err
+		}
+
+		if originalConversation == nil {
+			return nil, errors.ErrNotFound
+		}
+
+		originalMessage, err := service.Repository.GetMessageByID(originalMessageID)
+		if err != nil {
+			return nil, err
+		}
+
+		if originalMessage == nil {
+			return nil, errors.ErrNotFound
+		}
+
+		if originalMessage.Type == utils.MessageTypeSystem || !originalMessage.DeletedAt.IsZero() {
+			return nil, errors.ErrBadRequest
+		}
+
+		hasAccess, err := conversationRepository.IsUserInConversation(originalConversation.GetID(), userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasAccess {
+			return nil, errors.ErrForbidden
+		}
+
+		originalMessages = append(originalMessages, *originalMessage)
+	}
+
+	messageIDs, err := service.Repository.CreateForwardedMessages(userID, conversationIDs, originalMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]models.Message, 0, len(messageIDs))
+
+	for i, messageID := range messageIDs {
+		message, err := service.Repository.GetMessageByID(messageID)
+		if err != nil {
+			return nil, err
+		}
+
+		if message == nil {
+			return nil, errors.ErrInternal
+		}
+
+		signMessage(service.Signer, message)
+
+		publishToConversation(service.Events, service.Repository.Database, conversationIDs[i/len(originalMessages)], events.MessageForwarded, message)
+
+		messages = append(messages, *message)
+	}
+
+	return messages, nil
+}
+
 func (service *MessageService) UpdateMessage(messageID, userID uuid.UUID, content string) (*models.Message, error) {
 	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
 
@@ -125,7 +703,7 @@ func (service *MessageService) UpdateMessage(messageID, userID uuid.UUID, conten
 		return nil, errors.ErrNotFound
 	}
 
-	if message.IsForwarded {
+	if message.IsForwarded || message.Type == utils.MessageTypeSystem || !message.DeletedAt.IsZero() {
 		return nil, errors.ErrBadRequest
 	}
 
@@ -137,6 +715,19 @@ func (service *MessageService) UpdateMessage(messageID, userID uuid.UUID, conten
 		return nil, errors.ErrBadRequest
 	}
 
+	if service.EditWindow > 0 && globaltime.Now().Sub(message.SentAt) > service.EditWindow {
+		return nil, errors.ErrForbidden
+	}
+
+	if content == message.Content {
+		if err := applyMessageReactions(service.Repository.Database, conversation.GetID(), userID, message, service.ReactionDetailsMaxMembers); err != nil {
+			return nil, err
+		}
+
+		signMessage(service.Signer, message)
+		return message, nil
+	}
+
 	err = service.Repository.UpdateMessage(messageID, content)
 	if err != nil {
 		return nil, err
@@ -147,10 +738,48 @@ func (service *MessageService) UpdateMessage(messageID, userID uuid.UUID, conten
 		return nil, err
 	}
 
+	signMessage(service.Signer, updatedMessage)
+
+	editedMessage := *updatedMessage
+
+	if err := applyMessageReactions(service.Repository.Database, conversation.GetID(), uuid.Nil, &editedMessage, service.ReactionDetailsMaxMembers); err != nil {
+		return nil, err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversation.GetID(), events.MessageEdited, &editedMessage)
+
+	if err := applyMessageReactions(service.Repository.Database, conversation.GetID(), userID, updatedMessage, service.ReactionDetailsMaxMembers); err != nil {
+		return nil, err
+	}
+
 	return updatedMessage, nil
 }
 
-func (service *MessageService) DeleteMessage(messageID, userID uuid.UUID) error {
+func (service *MessageService) GetMessageRevisions(messageID, userID uuid.UUID) ([]models.MessageRevision, error) {
+	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
+
+	conversation, err := conversationRepository.GetConversationByMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	hasAccess, err := conversationRepository.IsUserInConversation(conversation.GetID(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasAccess {
+		return nil, errors.ErrForbidden
+	}
+
+	return service.Repository.GetMessageRevisions(messageID)
+}
+
+func (service *MessageService) DeleteMessage(messageID, userID uuid.UUID, scope string) error {
 	conversationRepository := &repositories.ConversationRepository{Database: service.Repository.Database}
 
 	conversation, err := conversationRepository.GetConversationByMessageID(messageID)
@@ -180,9 +809,40 @@ func (service *MessageService) DeleteMessage(messageID, userID uuid.UUID) error
 		return errors.ErrNotFound
 	}
 
+	if scope == utils.DeleteScopeMe {
+		if err := service.Repository.HideMessage(messageID, userID); err != nil {
+			return err
+		}
+
+		if service.Events != nil {
+			service.Events.Publish([]uuid.UUID{userID}, events.MessageDeleted, conversation.GetID(), events.MessageDeletedPayload{MessageID: messageID, Scope: scope})
+		}
+
+		return nil
+	}
+
+	if message.Type == utils.MessageTypeSystem {
+		return errors.ErrBadRequest
+	}
+
 	if message.Sender.ID != userID {
 		return errors.ErrForbidden
 	}
 
-	return service.Repository.DeleteMessage(messageID)
+	if !message.DeletedAt.IsZero() {
+		return nil
+	}
+
+	attachments, err := service.Repository.DeleteMessage(messageID)
+	if err != nil {
+		return err
+	}
+
+	if err := removeUploads(service.Store, attachments...); err != nil {
+		return err
+	}
+
+	publishToConversation(service.Events, service.Repository.Database, conversation.GetID(), events.MessageDeleted, events.MessageDeletedPayload{MessageID: messageID, Scope: scope})
+
+	return nil
 }
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/google/uuid"
+)
+
+func summarizeReactions(comments []models.Comment, viewerID uuid.UUID) []models.Reaction {
+	reactions := []models.Reaction{}
+	positions := map[string]int{}
+
+	for _, comment := range comments {
+		position, ok := positions[comment.Emoji]
+		if !ok {
+			position = len(reactions)
+			positions[comment.Emoji] = position
+			reactions = append(reactions, models.Reaction{Emoji: comment.Emoji})
+		}
+
+		reactions[position].Count++
+
+		if viewerID != uuid.Nil && comment.Commenter.ID == viewerID {
+			reactions[position].ReactedByMe = true
+		}
+	}
+
+	return reactions
+}
+
+func ownComments(comments []models.Comment, viewerID uuid.UUID) []models.Comment {
+	var own []models.Comment
+
+	for _, comment := range comments {
+		if viewerID != uuid.Nil && comment.Commenter.ID == viewerID {
+			own = append(own, comment)
+		}
+	}
+
+	return own
+}
+
+func hasReactionDetails(database database.Database, conversationID uuid.UUID, detailsMaxMembers int) (bool, error) {
+	conversationRepository := &repositories.ConversationRepository{Database: database}
+
+	userIDs, err := conversationRepository.GetUserIDsByConversationID(conversationID)
+	if err != nil {
+		return false, err
+	}
+
+	return len(userIDs) <= detailsMaxMembers, nil
+}
+
+func applyReactions(database database.Database, conversationID, viewerID uuid.UUID, messages []models.Message, detailsMaxMembers int) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	details, err := hasReactionDetails(database, conversationID, detailsMaxMembers)
+	if err != nil {
+		return err
+	}
+
+	for i := range messages {
+		messages[i].Reactions = summarizeReactions(messages[i].Comments, viewerID)
+
+		if !details {
+			messages[i].Comments = ownComments(messages[i].Comments, viewerID)
+		}
+	}
+
+	return nil
+}
+
+func applyMessageReactions(database database.Database, conversationID, viewerID uuid.UUID, message *models.Message, detailsMaxMembers int) error {
+	if message == nil {
+		return nil
+	}
+
+	messages := []models.Message{*message}
+
+	if err := applyReactions(database, conversationID, viewerID, messages, detailsMaxMembers); err != nil {
+		return err
+	}
+
+	*message = messages[0]
+
+	return nil
+}
+
+func applyConversationReactions(database database.Database, conversation models.Conversation, viewerID uuid.UUID, detailsMaxMembers int) error {
+	switch conv := conversation.(type) {
+	case *models.PrivateConversation:
+		if err := applyMessageReactions(database, conv.ID, viewerID, conv.LastMessage, detailsMaxMembers); err != nil {
+			return err
+		}
+
+		return applyReactions(database, conv.ID, viewerID, conv.Messages, detailsMaxMembers)
+	case *models.GroupConversation:
+		if err := applyMessageReactions(database, conv.ID, viewerID, conv.LastMessage, detailsMaxMembers); err != nil {
+			return err
+		}
+
+		return applyReactions(database, conv.ID, viewerID, conv.Messages, detailsMaxMembers)
+	}
+
+	return nil
+}
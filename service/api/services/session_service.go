@@ -0,0 +1,77 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+type SessionService struct {
+	Repository *repositories.SessionRepository
+	TTL        time.Duration
+}
+
+func (service *SessionService) CreateSession(userID uuid.UUID) (*models.Session, error) {
+	tokenBytes := make([]byte, 32)
+
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	sessionID, err := service.Repository.CreateSession(userID, token, globaltime.Now().Add(service.TTL))
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := service.Repository.GetSessionByID(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil {
+		return nil, errors.ErrInternal
+	}
+
+	session.Token = token
+	session.IsCurrent = true
+
+	return session, nil
+}
+
+func (service *SessionService) GetSessionsByUserID(userID, currentSessionID uuid.UUID) ([]models.Session, error) {
+	sessions, err := service.Repository.GetActiveSessionsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].IsCurrent = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+func (service *SessionService) RevokeSession(sessionID, userID uuid.UUID) error {
+	session, err := service.Repository.GetSessionByID(sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil {
+		return errors.ErrNotFound
+	}
+
+	if session.User.ID != userID {
+		return errors.ErrNotFound
+	}
+
+	return service.Repository.RevokeSession(sessionID)
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/storage"
+)
+
+func signMessage(signer *storage.URLSigner, message *models.Message) {
+	if message == nil {
+		return
+	}
+
+	if message.ReplyTo != nil {
+		preview := *message.ReplyTo
+		preview.Thumbnail = signer.Sign(preview.Thumbnail)
+
+		message.ReplyTo = &preview
+	}
+
+	if len(message.Attachments) == 0 {
+		return
+	}
+
+	attachments := make([]models.Attachment, len(message.Attachments))
+
+	for i, attachment := range message.Attachments {
+		attachment.URL = signer.Sign(attachment.URL)
+		attachment.Thumbnail = signer.Sign(attachment.Thumbnail)
+
+		attachments[i] = attachment
+	}
+
+	message.Attachments = attachments
+}
+
+func signMessages(signer *storage.URLSigner, messages []models.Message) {
+	for i := range messages {
+		signMessage(signer, &messages[i])
+	}
+}
+
+func signPins(signer *storage.URLSigner, pins []models.PinnedMessage) {
+	for i := range pins {
+		signMessage(signer, &pins[i].Message)
+	}
+}
+
+func signConversation(signer *storage.URLSigner, conversation models.Conversation) {
+	switch conv := conversation.(type) {
+	case *models.PrivateConversation:
+		signMessage(signer, conv.LastMessage)
+		signMessages(signer, conv.Messages)
+		signPins(signer, conv.Pins)
+	case *models.GroupConversation:
+		signMessage(signer, conv.LastMessage)
+		signMessages(signer, conv.Messages)
+		signPins(signer, conv.Pins)
+	}
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/evaevangelisti/wasatext/service/api/events"
+	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/database"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+	"github.com/google/uuid"
+)
+
+func markMessagesDelivered(hub *events.Hub, database database.Database, userID, conversationID uuid.UUID) error {
+	messageRepository := &repositories.MessageRepository{Database: database}
+
+	deliveredAt := globaltime.Now()
+
+	delivered, err := messageRepository.MarkMessagesDelivered(userID, conversationID, deliveredAt)
+	if err != nil {
+		return err
+	}
+
+	for cid, messageIDs := range delivered {
+		publishToConversation(hub, database, cid, events.MessagesDelivered, events.MessagesDeliveredPayload{
+			UserID:      userID,
+			MessageIDs:  messageIDs,
+			DeliveredAt: deliveredAt,
+		})
+	}
+
+	return nil
+}
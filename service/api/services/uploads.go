@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bytes"
+	stdErrors "errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/evaevangelisti/wasatext/service/api/models"
+	"github.com/evaevangelisti/wasatext/service/imaging"
+	"github.com/evaevangelisti/wasatext/service/media"
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/google/uuid"
+)
+
+func storeImage(store storage.BlobStore, directory string, upload *storage.Upload, thumbnail imaging.Variant) (string, string, error) {
+	if upload == nil {
+		return "", "", nil
+	}
+
+	result, err := processImage(upload, thumbnail)
+	if err != nil {
+		return "", "", err
+	}
+
+	return putImage(store, directory, result, thumbnail)
+}
+
+func storeAttachment(store storage.BlobStore, file *media.File) (*models.Attachment, error) {
+	if file == nil {
+		return nil, nil
+	}
+
+	attachment := &models.Attachment{
+		Type:     file.Kind,
+		MimeType: file.Upload.ContentType,
+		Filename: file.Filename,
+		Size:     int64(len(file.Upload.Data)),
+		Duration: file.Duration,
+	}
+
+	if file.Kind != media.KindImage {
+		key := storage.AttachmentsDir + "/" + uuid.New().String() + file.Upload.Extension
+
+		if err := putUpload(store, key, file.Upload); err != nil {
+			return nil, err
+		}
+
+		attachment.URL = storage.URL(key)
+
+		return attachment, nil
+	}
+
+	result, err := processImage(file.Upload, imaging.Preview)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.URL, attachment.Thumbnail, err = putImage(store, storage.AttachmentsDir, result, imaging.Preview)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment.MimeType = result.Image.ContentType
+	attachment.Size = int64(len(result.Image.Data))
+
+	if extension := filepath.Ext(attachment.Filename); !strings.EqualFold(extension, result.Image.Extension) {
+		attachment.Filename = strings.TrimSuffix(attachment.Filename, extension) + result.Image.Extension
+	}
+
+	return attachment, nil
+}
+
+func storeAttachments(store storage.BlobStore, files []*media.File) ([]models.Attachment, error) {
+	attachments := make([]models.Attachment, 0, len(files))
+
+	for _, file := range files {
+		attachment, err := storeAttachment(store, file)
+		if err != nil {
+			_ = removeAttachments(store, attachments)
+			return nil, err
+		}
+
+		if attachment != nil {
+			attachments = append(attachments, *attachment)
+		}
+	}
+
+	return attachments, nil
+}
+
+func removeAttachments(store storage.BlobStore, attachments []models.Attachment) error {
+	for _, attachment := range attachments {
+		if err := removeUploads(store, attachment.URL, attachment.Thumbnail); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func processImage(upload *storage.Upload, thumbnail imaging.Variant) (*imaging.Result, error) {
+	result, err := imaging.Process(upload, thumbnail)
+	if err != nil {
+		if stdErrors.Is(err, imaging.ErrInvalidImage) {
+			return nil, errors.ErrBadRequest
+		}
+
+		return nil, errors.ErrInternal
+	}
+
+	return result, nil
+}
+
+func putImage(store storage.BlobStore, directory string, result *imaging.Result, thumbnail imaging.Variant) (string, string, error) {
+	name := uuid.New().String()
+
+	key := directory + "/" + name + result.Image.Extension
+	if err := putUpload(store, key, result.Image); err != nil {
+		return "", "", err
+	}
+
+	thumbnailKey := directory + "/" + name + "_" + strconv.Itoa(thumbnail.Size) + result.Thumbnail.Extension
+	if err := putUpload(store, thumbnailKey, result.Thumbnail); err != nil {
+		_ = store.Delete(key)
+		return "", "", err
+	}
+
+	return storage.URL(key), storage.URL(thumbnailKey), nil
+}
+
+func putUpload(store storage.BlobStore, key string, upload *storage.Upload) error {
+	if err := store.Put(key, bytes.NewReader(upload.Data), int64(len(upload.Data)), upload.ContentType); err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func removeUploads(store storage.BlobStore, urls ...string) error {
+	for _, url := range urls {
+		key, ok := storage.KeyFromURL(url)
+		if !ok {
+			continue
+		}
+
+		if err := store.Delete(key); err != nil && !stdErrors.Is(err, storage.ErrNotFound) {
+			return errors.ErrInternal
+		}
+	}
+
+	return nil
+}
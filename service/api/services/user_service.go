@@ -3,29 +3,56 @@ package services
 import (
 	"github.com/evaevangelisti/wasatext/service/api/models"
 	"github.com/evaevangelisti/wasatext/service/api/repositories"
+	"github.com/evaevangelisti/wasatext/service/imaging"
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/evaevangelisti/wasatext/service/utils"
 	"github.com/evaevangelisti/wasatext/service/utils/errors"
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
 	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService struct {
 	Repository *repositories.UserRepository
+	Store      storage.BlobStore
 }
 
 func (service *UserService) GetUsers(q string, authenticatedUserID uuid.UUID) ([]models.User, error) {
 	return service.Repository.GetUsers(q, authenticatedUserID)
 }
 
-func (service *UserService) DoLogin(username string) (*models.User, bool, error) {
+func (service *UserService) DoLogin(username string, password string, client string) (*models.User, bool, error) {
+	credentialRepository := &repositories.CredentialRepository{Database: service.Repository.Database}
+
 	user, err := service.Repository.GetUserByUsername(username)
 	if err != nil {
 		return nil, false, err
 	}
 
 	if user != nil {
+		credential, err := credentialRepository.GetCredentialByUserID(user.ID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if credential != nil {
+			if err := verifyPassword(credentialRepository, credential, client, password); err != nil {
+				return nil, false, err
+			}
+		}
+
 		return user, false, nil
 	}
 
-	userID, err := service.Repository.CreateUser(username)
+	var passwordHash string
+	if password != "" {
+		passwordHash, err = hashPassword(password)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	userID, err := service.Repository.CreateUser(username, passwordHash)
 	if err != nil {
 		return nil, false, err
 	}
@@ -38,6 +65,33 @@ func (service *UserService) DoLogin(username string) (*models.User, bool, error)
 	return user, true, nil
 }
 
+func (service *UserService) UpdatePassword(userID uuid.UUID, sessionID uuid.UUID, client string, currentPassword string, newPassword string) error {
+	credentialRepository := &repositories.CredentialRepository{Database: service.Repository.Database}
+	sessionRepository := &repositories.SessionRepository{Database: service.Repository.Database}
+
+	credential, err := credentialRepository.GetCredentialByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	if credential != nil {
+		if err := verifyPassword(credentialRepository, credential, client, currentPassword); err != nil {
+			return err
+		}
+	}
+
+	passwordHash, err := hashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := credentialRepository.SetPasswordHash(userID, passwordHash); err != nil {
+		return err
+	}
+
+	return sessionRepository.RevokeSessionsByUserID(userID, sessionID)
+}
+
 func (service *UserService) UpdateUsername(userID uuid.UUID, username string) (*models.User, error) {
 	existingUser, err := service.Repository.GetUserByUsername(username)
 	if err != nil {
@@ -61,9 +115,19 @@ func (service *UserService) UpdateUsername(userID uuid.UUID, username string) (*
 	return user, nil
 }
 
-func (service *UserService) UpdateProfilePicture(userID uuid.UUID, profilePicture string) (*models.User, error) {
-	err := service.Repository.UpdateProfilePicture(userID, profilePicture)
+func (service *UserService) UpdateProfilePicture(userID uuid.UUID, image *storage.Upload) (*models.User, error) {
+	profilePicture, profilePictureThumbnail, err := storeImage(service.Store, storage.ProfilePicturesDir, image, imaging.Avatar)
+	if err != nil {
+		return nil, err
+	}
+
+	oldProfilePictures, err := service.Repository.UpdateProfilePicture(userID, profilePicture, profilePictureThumbnail)
 	if err != nil {
+		_ = removeUploads(service.Store, profilePicture, profilePictureThumbnail)
+		return nil, err
+	}
+
+	if err := removeUploads(service.Store, oldProfilePictures...); err != nil {
 		return nil, err
 	}
 
@@ -74,3 +138,107 @@ func (service *UserService) UpdateProfilePicture(userID uuid.UUID, profilePictur
 
 	return user, nil
 }
+
+func hashPassword(password string) (string, error) {
+	if len(password) < utils.PasswordMinLength || len(password) > utils.PasswordMaxLength {
+		return "", errors.ErrBadRequest
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	return string(passwordHash), nil
+}
+
+func verifyPassword(credentialRepository *repositories.CredentialRepository, credential *models.Credential, client string, password string) error {
+	attempt, err := credentialRepository.RecordLoginAttempt(credential.UserID, client, globaltime.Now(), utils.MaxFailedLoginAttempts, utils.LoginLockoutDuration)
+	if err != nil {
+		return err
+	}
+
+	if attempt.LockedUntil != nil {
+		return errors.ErrTooManyRequests
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(credential.PasswordHash), []byte(password)); err != nil {
+		return errors.ErrUnauthorized
+	}
+
+	return credentialRepository.ResetFailedAttempts(credential.UserID, client)
+}
+
+func (service *UserService) GetBlockedUsers(userID uuid.UUID) ([]models.User, error) {
+	blockRepository := &repositories.BlockRepository{Database: service.Repository.Database}
+
+	return blockRepository.GetBlockedUsers(userID)
+}
+
+func (service *UserService) BlockUser(userID, blockedID uuid.UUID) (*models.User, error) {
+	blockRepository := &repositories.BlockRepository{Database: service.Repository.Database}
+
+	if userID == blockedID {
+		return nil, errors.ErrBadRequest
+	}
+
+	blockedUser, err := service.Repository.GetUserByID(blockedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if blockedUser == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	blocked, err := blockRepository.IsUserBlocked(userID, blockedID)
+	if err != nil {
+		return nil, err
+	}
+
+	if blocked {
+		return nil, errors.ErrConflict
+	}
+
+	if err := blockRepository.BlockUser(userID, blockedID); err != nil {
+		return nil, err
+	}
+
+	return blockedUser, nil
+}
+
+func (service *UserService) UnblockUser(userID, blockedID uuid.UUID) error {
+	blockRepository := &repositories.BlockRepository{Database: service.Repository.Database}
+
+	blocked, err := blockRepository.IsUserBlocked(userID, blockedID)
+	if err != nil {
+		return err
+	}
+
+	if !blocked {
+		return errors.ErrNotFound
+	}
+
+	return blockRepository.UnblockUser(userID, blockedID)
+}
+
+func (service *UserService) GetPrivacySettings(userID uuid.UUID) (*models.PrivacySettings, error) {
+	settings, err := service.Repository.GetPrivacySettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		return nil, errors.ErrNotFound
+	}
+
+	return settings, nil
+}
+
+func (service *UserService) UpdatePrivacySettings(userID uuid.UUID, settings models.PrivacySettings) (*models.PrivacySettings, error) {
+	if err := service.Repository.UpdatePrivacySettings(userID, settings); err != nil {
+		return nil, err
+	}
+
+	return service.GetPrivacySettings(userID)
+}
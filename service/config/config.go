@@ -22,15 +22,53 @@ type WebAPIConfig struct {
 		DebugHost       string        `conf:"default:0.0.0.0:4000"`
 		ReadTimeout     time.Duration `conf:"default:5s"`
 		WriteTimeout    time.Duration `conf:"default:5s"`
+		TransferTimeout time.Duration `conf:"default:10m"`
 		ShutdownTimeout time.Duration `conf:"default:5s"`
 	}
 
+	Auth struct {
+		SessionTTL time.Duration `conf:"default:720h"`
+	}
+
 	Database struct {
-		FilePath       string `conf:"default:./tmp/wasatext.db"`
-		MigrationsPath string `conf:"default:./service/database/migrations"`
+		FilePath string `conf:"default:./tmp/wasatext.db"`
+	}
+
+	Storage struct {
+		Backend      string        `conf:"default:local"`
+		Root         string        `conf:"default:./tmp/uploads"`
+		SigningKey   string        `conf:"mask"`
+		SignedURLTTL time.Duration `conf:"default:1h,flag:storage-signed-url-ttl,env:STORAGE_SIGNED_URL_TTL"`
+
+		S3 struct {
+			Endpoint        string `conf:"flag:storage-s3-endpoint,env:STORAGE_S3_ENDPOINT"`
+			Region          string `conf:"default:us-east-1,flag:storage-s3-region,env:STORAGE_S3_REGION"`
+			Bucket          string `conf:"flag:storage-s3-bucket,env:STORAGE_S3_BUCKET"`
+			AccessKeyID     string `conf:"flag:storage-s3-access-key-id,env:STORAGE_S3_ACCESS_KEY_ID"`
+			SecretAccessKey string `conf:"mask,flag:storage-s3-secret-access-key,env:STORAGE_S3_SECRET_ACCESS_KEY"`
+			Prefix          string `conf:"flag:storage-s3-prefix,env:STORAGE_S3_PREFIX"`
+		}
+	}
+
+	Messages struct {
+		EditWindow       time.Duration `conf:"default:0s"`
+		DispatchInterval time.Duration `conf:"default:1s"`
+
+		ReactionDetailsMaxMembers int `conf:"default:20"`
+	}
+
+	Attachments struct {
+		AllowedTypes    []string `conf:"default:image/*;audio/*;video/*;application/pdf;application/zip;application/msword;application/vnd.ms-*;application/vnd.openxmlformats-officedocument.*;application/vnd.oasis.opendocument.*;text/plain;text/csv"`
+		MaxImageSize    int64    `conf:"default:5242880"`
+		MaxAudioSize    int64    `conf:"default:16777216"`
+		MaxVideoSize    int64    `conf:"default:67108864"`
+		MaxDocumentSize int64    `conf:"default:33554432"`
+		MaxTotalSize    int64    `conf:"default:104857600"`
 	}
 
 	Debug bool
+
+	Args conf.Args
 }
 
 func LoadConfig() (WebAPIConfig, error) {
@@ -3,11 +3,6 @@ package database
 import (
 	"database/sql"
 	"errors"
-	"fmt"
-	"io"
-	"os"
-	"path/filepath"
-	"strings"
 )
 
 type Database interface {
@@ -17,55 +12,31 @@ type Database interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	Ping() error
 	Close() error
+	Supports(feature string) bool
 }
 
 type databaseImpl struct {
 	connection *sql.DB
+	features   map[string]bool
 }
 
-func New(db *sql.DB, migrationsPath string) (Database, error) {
+func New(db *sql.DB) (Database, error) {
 	if db == nil {
 		return nil, errors.New("database connection is required")
 	}
 
-	if migrationsPath == "" {
-		return nil, errors.New("migrations path is required")
+	if _, err := MigrateUp(db, 0); err != nil {
+		return nil, err
 	}
 
-	migrations, err := os.ReadDir(migrationsPath)
-
+	features, err := Features(db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
-	}
-
-	for _, migration := range migrations {
-		if strings.HasSuffix(migration.Name(), ".sql") {
-			migrationPath := filepath.Join(migrationsPath, migration.Name())
-
-			migrationFile, err := os.Open(migrationPath)
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to open migration file %s: %w", migration.Name(), err)
-			}
-
-			defer migrationFile.Close()
-
-			migrationSQL, err := io.ReadAll(migrationFile)
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to read migration file %s: %w", migration.Name(), err)
-			}
-
-			_, err = db.Exec(string(migrationSQL))
-
-			if err != nil {
-				return nil, fmt.Errorf("failed to execute migration %s: %w", migration.Name(), err)
-			}
-		}
+		return nil, err
 	}
 
 	return &databaseImpl{
 		connection: db,
+		features:   features,
 	}, nil
 }
 
@@ -92,3 +63,7 @@ func (db *databaseImpl) Ping() error {
 func (db *databaseImpl) Close() error {
 	return db.connection.Close()
 }
+
+func (db *databaseImpl) Supports(feature string) bool {
+	return db.features[feature]
+}
@@ -0,0 +1,317 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+const (
+	FeatureFTS5 = "fts5"
+
+	requiresPrefix = "-- requires:"
+)
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Requires []string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	Supported bool
+}
+
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	migrationsByVersion := map[int]*Migration{}
+
+	for _, entry := range entries {
+		filename := entry.Name()
+
+		var direction string
+
+		switch {
+		case strings.HasSuffix(filename, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(filename, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(filename, "."+direction+".sql")
+
+		separator := strings.Index(base, "_")
+		if separator <= 0 {
+			return nil, fmt.Errorf("invalid migration filename %s", filename)
+		}
+
+		version, err := strconv.Atoi(base[:separator])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", filename, err)
+		}
+
+		content, err := migrationsFS.ReadFile("migrations/" + filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", filename, err)
+		}
+
+		migration, ok := migrationsByVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: base[separator+1:]}
+			migrationsByVersion[version] = migration
+		} else if migration.Name != base[separator+1:] {
+			return nil, fmt.Errorf("conflicting migrations for version %d", version)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+			migration.Requires = migrationRequirements(migration.Up)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(migrationsByVersion))
+
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up script", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func migrationRequirements(script string) []string {
+	firstLine := strings.SplitN(script, "\n", 2)[0]
+	if !strings.HasPrefix(firstLine, requiresPrefix) {
+		return nil
+	}
+
+	return strings.Fields(strings.ReplaceAll(strings.TrimPrefix(firstLine, requiresPrefix), ",", " "))
+}
+
+func migrationSupported(migration Migration, features map[string]bool) bool {
+	for _, feature := range migration.Requires {
+		if !features[feature] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func Features(db *sql.DB) (map[string]bool, error) {
+	var fts5 bool
+
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return nil, fmt.Errorf("failed to read sqlite compile options: %w", err)
+	}
+
+	return map[string]bool{FeatureFTS5: fts5}, nil
+}
+
+func MigrationStatuses(db *sql.DB) ([]MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	appliedAt, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	features, err := Features(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration, Supported: migrationSupported(migration, features)}
+
+		if t, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &t
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func MigrateUp(db *sql.DB, steps int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := []Migration{}
+
+	for _, status := range statuses {
+		if steps > 0 && len(applied) == steps {
+			break
+		}
+
+		if status.AppliedAt != nil {
+			if !status.Supported {
+				return applied, fmt.Errorf("migration %03d_%s requires %s, which this build of sqlite does not provide", status.Version, status.Name, strings.Join(status.Requires, ", "))
+			}
+
+			continue
+		}
+
+		if !status.Supported {
+			continue
+		}
+
+		err := runMigration(db, status.Migration.Up, func(tx *sql.Tx) error {
+			_, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", status.Version, status.Name, globaltime.Format(globaltime.Now()))
+			return err
+		})
+
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %03d_%s: %w", status.Version, status.Name, err)
+		}
+
+		applied = append(applied, status.Migration)
+	}
+
+	return applied, nil
+}
+
+func MigrateDown(db *sql.DB, steps int) ([]Migration, error) {
+	statuses, err := MigrationStatuses(db)
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := []Migration{}
+
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if steps > 0 && len(reverted) == steps {
+			break
+		}
+
+		status := statuses[i]
+
+		if status.AppliedAt == nil {
+			continue
+		}
+
+		if status.Down == "" {
+			return reverted, fmt.Errorf("migration %03d_%s has no down script", status.Version, status.Name)
+		}
+
+		err := runMigration(db, status.Down, func(tx *sql.Tx) error {
+			_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", status.Version)
+			return err
+		})
+
+		if err != nil {
+			return reverted, fmt.Errorf("failed to revert migration %03d_%s: %w", status.Version, status.Name, err)
+		}
+
+		reverted = append(reverted, status.Migration)
+	}
+
+	return reverted, nil
+}
+
+func appliedMigrations(db *sql.DB) (map[int]time.Time, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TEXT NOT NULL CHECK (
+        applied_at LIKE "____-__-__T__:__:__Z" OR
+        applied_at LIKE "____-__-__T__:__:__+__:__" OR
+        applied_at LIKE "____-__-__T__:__:__-__:__"
+    )
+)`)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	rows, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations table: %w", err)
+	}
+
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+
+	for rows.Next() {
+		var (
+			version int
+			at      string
+		)
+
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations table: %w", err)
+		}
+
+		t, err := globaltime.Parse(at)
+		if err != nil {
+			return nil, fmt.Errorf("invalid applied_at for migration %d: %w", version, err)
+		}
+
+		appliedAt[version] = t
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations table: %w", err)
+	}
+
+	return appliedAt, nil
+}
+
+func runMigration(db *sql.DB, script string, record func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_participants_conversation_id;
+DROP TABLE IF EXISTS participants;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_members_conversation_id;
+DROP TABLE IF EXISTS members;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS forwarded_messages;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_messages_conversation_id_sent_at ON messages (conversation_id, sent_at);
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    session_id TEXT PRIMARY KEY CHECK (
+        session_id LIKE '________-____-____-____-____________'
+    ),
+    token_hash TEXT NOT NULL UNIQUE CHECK (
+        LENGTH (token_hash) = 64
+    ),
+    user_id TEXT NOT NULL CHECK (
+        user_id LIKE '________-____-____-____-____________'
+    ),
+    created_at TEXT NOT NULL CHECK (
+        created_at LIKE "____-__-__T__:__:__Z" OR
+        created_at LIKE "____-__-__T__:__:__+__:__" OR
+        created_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    expires_at TEXT NOT NULL CHECK (
+        expires_at LIKE "____-__-__T__:__:__Z" OR
+        expires_at LIKE "____-__-__T__:__:__+__:__" OR
+        expires_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    revoked_at TEXT CHECK (
+        revoked_at LIKE "____-__-__T__:__:__Z" OR
+        revoked_at LIKE "____-__-__T__:__:__+__:__" OR
+        revoked_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS user_credentials (
+    user_id TEXT PRIMARY KEY CHECK (
+        user_id LIKE '________-____-____-____-____________'
+    ),
+    password_hash TEXT NOT NULL CHECK (
+        LENGTH (password_hash) = 60
+    ),
+    updated_at TEXT NOT NULL CHECK (
+        updated_at LIKE "____-__-__T__:__:__Z" OR
+        updated_at LIKE "____-__-__T__:__:__+__:__" OR
+        updated_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    user_id TEXT NOT NULL CHECK (
+        user_id LIKE '________-____-____-____-____________'
+    ),
+    client TEXT NOT NULL CHECK (
+        LENGTH (client) >= 1
+        AND LENGTH (client) <= 64
+    ),
+    failed_attempts INTEGER NOT NULL DEFAULT 0 CHECK (
+        failed_attempts >= 0
+    ),
+    locked_until TEXT CHECK (
+        locked_until LIKE "____-__-__T__:__:__Z" OR
+        locked_until LIKE "____-__-__T__:__:__+__:__" OR
+        locked_until LIKE "____-__-__T__:__:__-__:__"
+    ),
+    updated_at TEXT NOT NULL CHECK (
+        updated_at LIKE "____-__-__T__:__:__Z" OR
+        updated_at LIKE "____-__-__T__:__:__+__:__" OR
+        updated_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    PRIMARY KEY (user_id, client),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
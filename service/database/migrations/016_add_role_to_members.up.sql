@@ -0,0 +1,7 @@
+ALTER TABLE members ADD COLUMN role TEXT NOT NULL DEFAULT 'member' CHECK (
+    role IN ('owner', 'admin', 'member')
+);
+
+UPDATE members SET role = 'owner' WHERE rowid IN (
+    SELECT MIN(rowid) FROM members GROUP BY conversation_id
+);
@@ -0,0 +1,4 @@
+ALTER TABLE group_conversations DROP COLUMN post_permission;
+ALTER TABLE group_conversations DROP COLUMN add_members_permission;
+ALTER TABLE group_conversations DROP COLUMN photo_permission;
+ALTER TABLE group_conversations DROP COLUMN rename_permission;
@@ -0,0 +1,15 @@
+ALTER TABLE group_conversations ADD COLUMN rename_permission TEXT NOT NULL DEFAULT 'members' CHECK (
+    rename_permission IN ('members', 'admins')
+);
+
+ALTER TABLE group_conversations ADD COLUMN photo_permission TEXT NOT NULL DEFAULT 'members' CHECK (
+    photo_permission IN ('members', 'admins')
+);
+
+ALTER TABLE group_conversations ADD COLUMN add_members_permission TEXT NOT NULL DEFAULT 'members' CHECK (
+    add_members_permission IN ('members', 'admins')
+);
+
+ALTER TABLE group_conversations ADD COLUMN post_permission TEXT NOT NULL DEFAULT 'members' CHECK (
+    post_permission IN ('members', 'admins')
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_message_trackings_user_id;
+DELETE FROM message_trackings WHERE read_at IS NULL;
+ALTER TABLE message_trackings DROP COLUMN delivered_at;
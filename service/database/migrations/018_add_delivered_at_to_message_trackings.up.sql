@@ -0,0 +1,9 @@
+ALTER TABLE message_trackings ADD COLUMN delivered_at TEXT CHECK (
+    delivered_at LIKE "____-__-__T__:__:__Z" OR
+    delivered_at LIKE "____-__-__T__:__:__+__:__" OR
+    delivered_at LIKE "____-__-__T__:__:__-__:__"
+);
+
+UPDATE message_trackings SET delivered_at = read_at WHERE read_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_message_trackings_user_id ON message_trackings (user_id);
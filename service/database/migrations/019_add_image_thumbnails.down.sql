@@ -0,0 +1,3 @@
+ALTER TABLE messages DROP COLUMN attachment_thumbnail;
+ALTER TABLE group_conversations DROP COLUMN photo_thumbnail;
+ALTER TABLE users DROP COLUMN profile_picture_thumbnail;
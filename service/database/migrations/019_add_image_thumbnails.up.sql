@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN profile_picture_thumbnail TEXT CHECK (
+    LENGTH (profile_picture_thumbnail) >= 11
+    AND LENGTH (profile_picture_thumbnail) <= 255
+);
+
+ALTER TABLE group_conversations ADD COLUMN photo_thumbnail TEXT CHECK (
+    LENGTH (photo_thumbnail) >= 11
+    AND LENGTH (photo_thumbnail) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_thumbnail TEXT CHECK (
+    LENGTH (attachment_thumbnail) >= 11
+    AND LENGTH (attachment_thumbnail) <= 255
+);
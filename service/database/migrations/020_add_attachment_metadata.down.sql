@@ -0,0 +1,5 @@
+ALTER TABLE messages DROP COLUMN attachment_duration;
+ALTER TABLE messages DROP COLUMN attachment_size;
+ALTER TABLE messages DROP COLUMN attachment_filename;
+ALTER TABLE messages DROP COLUMN attachment_mime_type;
+ALTER TABLE messages DROP COLUMN attachment_type;
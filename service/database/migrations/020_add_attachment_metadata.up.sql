@@ -0,0 +1,28 @@
+ALTER TABLE messages ADD COLUMN attachment_type TEXT CHECK (
+    attachment_type IN ('image', 'audio', 'video', 'document')
+);
+
+ALTER TABLE messages ADD COLUMN attachment_mime_type TEXT CHECK (
+    LENGTH (attachment_mime_type) >= 1
+    AND LENGTH (attachment_mime_type) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_filename TEXT CHECK (
+    LENGTH (attachment_filename) >= 1
+    AND LENGTH (attachment_filename) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_size INTEGER CHECK (attachment_size >= 0);
+
+ALTER TABLE messages ADD COLUMN attachment_duration REAL CHECK (attachment_duration >= 0);
+
+UPDATE messages
+SET
+    attachment_type = 'image',
+    attachment_mime_type = CASE
+        WHEN attachment LIKE '%.png' THEN 'image/png'
+        WHEN attachment LIKE '%.webp' THEN 'image/webp'
+        ELSE 'image/jpeg'
+    END
+WHERE
+    attachment IS NOT NULL;
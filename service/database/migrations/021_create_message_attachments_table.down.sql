@@ -0,0 +1,67 @@
+ALTER TABLE messages ADD COLUMN attachment TEXT CHECK (
+    LENGTH (attachment) >= 11
+    AND LENGTH (attachment) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_thumbnail TEXT CHECK (
+    LENGTH (attachment_thumbnail) >= 11
+    AND LENGTH (attachment_thumbnail) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_type TEXT CHECK (
+    attachment_type IN ('image', 'audio', 'video', 'document')
+);
+
+ALTER TABLE messages ADD COLUMN attachment_mime_type TEXT CHECK (
+    LENGTH (attachment_mime_type) >= 1
+    AND LENGTH (attachment_mime_type) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_filename TEXT CHECK (
+    LENGTH (attachment_filename) >= 1
+    AND LENGTH (attachment_filename) <= 255
+);
+
+ALTER TABLE messages ADD COLUMN attachment_size INTEGER CHECK (attachment_size >= 0);
+
+ALTER TABLE messages ADD COLUMN attachment_duration REAL CHECK (attachment_duration >= 0);
+
+UPDATE messages
+SET
+    (
+        attachment,
+        attachment_thumbnail,
+        attachment_type,
+        attachment_mime_type,
+        attachment_filename,
+        attachment_size,
+        attachment_duration
+    ) = (
+        SELECT
+            url,
+            thumbnail,
+            type,
+            mime_type,
+            filename,
+            size,
+            duration
+        FROM
+            message_attachments a
+        WHERE
+            a.message_id = messages.message_id
+        ORDER BY
+            a.position ASC
+        LIMIT
+            1
+    )
+WHERE
+    message_id IN (
+        SELECT
+            message_id
+        FROM
+            message_attachments
+    );
+
+DROP INDEX IF EXISTS idx_message_attachments_thumbnail;
+DROP INDEX IF EXISTS idx_message_attachments_url;
+DROP TABLE IF EXISTS message_attachments;
@@ -0,0 +1,68 @@
+CREATE TABLE IF NOT EXISTS message_attachments (
+    message_id TEXT NOT NULL CHECK (
+        message_id LIKE '________-____-____-____-____________'
+    ),
+    position INTEGER NOT NULL CHECK (position >= 0),
+    url TEXT NOT NULL CHECK (
+        LENGTH (url) >= 11
+        AND LENGTH (url) <= 255
+    ),
+    thumbnail TEXT CHECK (
+        LENGTH (thumbnail) >= 11
+        AND LENGTH (thumbnail) <= 255
+    ),
+    type TEXT NOT NULL CHECK (
+        type IN ('image', 'audio', 'video', 'document')
+    ),
+    mime_type TEXT NOT NULL CHECK (
+        LENGTH (mime_type) >= 1
+        AND LENGTH (mime_type) <= 255
+    ),
+    filename TEXT CHECK (
+        LENGTH (filename) >= 1
+        AND LENGTH (filename) <= 255
+    ),
+    size INTEGER NOT NULL CHECK (size >= 0),
+    duration REAL CHECK (duration >= 0),
+    PRIMARY KEY (message_id, position),
+    FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_attachments_url ON message_attachments (url);
+
+CREATE INDEX IF NOT EXISTS idx_message_attachments_thumbnail ON message_attachments (thumbnail);
+
+INSERT INTO
+    message_attachments (
+        message_id,
+        position,
+        url,
+        thumbnail,
+        type,
+        mime_type,
+        filename,
+        size,
+        duration
+    )
+SELECT
+    message_id,
+    0,
+    attachment,
+    attachment_thumbnail,
+    COALESCE(attachment_type, 'image'),
+    COALESCE(attachment_mime_type, 'application/octet-stream'),
+    attachment_filename,
+    COALESCE(attachment_size, 0),
+    attachment_duration
+FROM
+    messages
+WHERE
+    attachment IS NOT NULL;
+
+ALTER TABLE messages DROP COLUMN attachment_duration;
+ALTER TABLE messages DROP COLUMN attachment_size;
+ALTER TABLE messages DROP COLUMN attachment_filename;
+ALTER TABLE messages DROP COLUMN attachment_mime_type;
+ALTER TABLE messages DROP COLUMN attachment_type;
+ALTER TABLE messages DROP COLUMN attachment_thumbnail;
+ALTER TABLE messages DROP COLUMN attachment;
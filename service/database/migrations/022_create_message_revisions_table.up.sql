@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS message_revisions (
+    revision_id TEXT PRIMARY KEY CHECK (
+        revision_id LIKE '________-____-____-____-____________'
+    ),
+    message_id TEXT NOT NULL CHECK (
+        message_id LIKE '________-____-____-____-____________'
+    ),
+    content TEXT CHECK (
+        LENGTH (content) >= 1
+        AND LENGTH (content) <= 1000
+    ),
+    created_at TEXT NOT NULL CHECK (
+        created_at LIKE "____-__-__T__:__:__Z" OR
+        created_at LIKE "____-__-__T__:__:__+__:__" OR
+        created_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    replaced_at TEXT NOT NULL CHECK (
+        replaced_at LIKE "____-__-__T__:__:__Z" OR
+        replaced_at LIKE "____-__-__T__:__:__+__:__" OR
+        replaced_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_revisions_message_id ON message_revisions (message_id, replaced_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_hidden_messages_user_id;
+DROP TABLE IF EXISTS hidden_messages;
+ALTER TABLE messages DROP COLUMN deleted_at;
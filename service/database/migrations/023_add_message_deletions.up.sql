@@ -0,0 +1,24 @@
+ALTER TABLE messages ADD COLUMN deleted_at TEXT CHECK (
+    deleted_at LIKE "____-__-__T__:__:__Z" OR
+    deleted_at LIKE "____-__-__T__:__:__+__:__" OR
+    deleted_at LIKE "____-__-__T__:__:__-__:__"
+);
+
+CREATE TABLE IF NOT EXISTS hidden_messages (
+    message_id TEXT NOT NULL CHECK (
+        message_id LIKE '________-____-____-____-____________'
+    ),
+    user_id TEXT NOT NULL CHECK (
+        user_id LIKE '________-____-____-____-____________'
+    ),
+    hidden_at TEXT NOT NULL CHECK (
+        hidden_at LIKE "____-__-__T__:__:__Z" OR
+        hidden_at LIKE "____-__-__T__:__:__+__:__" OR
+        hidden_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    PRIMARY KEY (message_id, user_id),
+    FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_hidden_messages_user_id ON hidden_messages (user_id);
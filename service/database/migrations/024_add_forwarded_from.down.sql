@@ -0,0 +1,2 @@
+ALTER TABLE forwarded_messages DROP COLUMN original_sent_at;
+ALTER TABLE forwarded_messages DROP COLUMN original_sender_id;
@@ -0,0 +1,25 @@
+ALTER TABLE forwarded_messages ADD COLUMN original_sender_id TEXT CHECK (
+    original_sender_id LIKE '________-____-____-____-____________'
+);
+
+ALTER TABLE forwarded_messages ADD COLUMN original_sent_at TEXT CHECK (
+    original_sent_at LIKE "____-__-__T__:__:__Z" OR
+    original_sent_at LIKE "____-__-__T__:__:__+__:__" OR
+    original_sent_at LIKE "____-__-__T__:__:__-__:__"
+);
+
+UPDATE forwarded_messages SET (original_sender_id, original_sent_at) = (
+    WITH RECURSIVE chain (message_id, depth) AS (
+        SELECT forwarded_messages.original_message_id, 0
+        UNION ALL
+        SELECT f.original_message_id, chain.depth + 1
+        FROM forwarded_messages f
+        JOIN chain ON f.forwarded_message_id = chain.message_id
+        WHERE chain.depth < 100
+    )
+    SELECT m.sender_id, m.sent_at
+    FROM chain
+    JOIN messages m ON m.message_id = chain.message_id
+    ORDER BY chain.depth DESC
+    LIMIT 1
+);
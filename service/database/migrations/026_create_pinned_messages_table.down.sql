@@ -0,0 +1,3 @@
+ALTER TABLE group_conversations DROP COLUMN pin_permission;
+DROP INDEX IF EXISTS idx_pinned_messages_message_id;
+DROP TABLE IF EXISTS pinned_messages;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS pinned_messages (
+    conversation_id TEXT NOT NULL CHECK (
+        conversation_id LIKE '________-____-____-____-____________'
+    ),
+    message_id TEXT NOT NULL CHECK (
+        message_id LIKE '________-____-____-____-____________'
+    ),
+    pinned_by TEXT CHECK (
+        pinned_by LIKE '________-____-____-____-____________'
+    ),
+    pinned_at TEXT NOT NULL CHECK (
+        pinned_at LIKE "____-__-__T__:__:__Z" OR
+        pinned_at LIKE "____-__-__T__:__:__+__:__" OR
+        pinned_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    PRIMARY KEY (conversation_id, message_id),
+    FOREIGN KEY (conversation_id) REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE,
+    FOREIGN KEY (pinned_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_pinned_messages_message_id ON pinned_messages (message_id);
+
+ALTER TABLE group_conversations ADD COLUMN pin_permission TEXT NOT NULL DEFAULT 'members' CHECK (
+    pin_permission IN ('members', 'admins')
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_starred_messages_message_id;
+DROP TABLE IF EXISTS starred_messages;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS starred_messages (
+    user_id TEXT NOT NULL CHECK (
+        user_id LIKE '________-____-____-____-____________'
+    ),
+    message_id TEXT NOT NULL CHECK (
+        message_id LIKE '________-____-____-____-____________'
+    ),
+    starred_at TEXT NOT NULL CHECK (
+        starred_at LIKE "____-__-__T__:__:__Z" OR
+        starred_at LIKE "____-__-__T__:__:__+__:__" OR
+        starred_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    PRIMARY KEY (user_id, message_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_starred_messages_message_id ON starred_messages (message_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_conversation_settings_conversation_id;
+DROP TABLE IF EXISTS conversation_settings;
@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS conversation_settings (
+    user_id TEXT NOT NULL CHECK (
+        user_id LIKE '________-____-____-____-____________'
+    ),
+    conversation_id TEXT NOT NULL CHECK (
+        conversation_id LIKE '________-____-____-____-____________'
+    ),
+    muted_until TEXT CHECK (
+        muted_until LIKE "____-__-__T__:__:__Z" OR
+        muted_until LIKE "____-__-__T__:__:__+__:__" OR
+        muted_until LIKE "____-__-__T__:__:__-__:__"
+    ),
+    archived INTEGER NOT NULL DEFAULT 0 CHECK (
+        archived IN (0, 1)
+    ),
+    pinned INTEGER NOT NULL DEFAULT 0 CHECK (
+        pinned IN (0, 1)
+    ),
+    updated_at TEXT NOT NULL CHECK (
+        updated_at LIKE "____-__-__T__:__:__Z" OR
+        updated_at LIKE "____-__-__T__:__:__+__:__" OR
+        updated_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    PRIMARY KEY (user_id, conversation_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (conversation_id) REFERENCES conversations (conversation_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversation_settings_conversation_id ON conversation_settings (conversation_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN group_add_privacy;
+DROP INDEX IF EXISTS idx_blocks_blocked_id;
+DROP TABLE IF EXISTS blocks;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS blocks (
+    blocker_id TEXT NOT NULL CHECK (
+        blocker_id LIKE '________-____-____-____-____________'
+    ),
+    blocked_id TEXT NOT NULL CHECK (
+        blocked_id LIKE '________-____-____-____-____________'
+    ),
+    blocked_at TEXT NOT NULL CHECK (
+        blocked_at LIKE "____-__-__T__:__:__Z" OR
+        blocked_at LIKE "____-__-__T__:__:__+__:__" OR
+        blocked_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    PRIMARY KEY (blocker_id, blocked_id),
+    FOREIGN KEY (blocker_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    CHECK (blocker_id != blocked_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_blocks_blocked_id ON blocks (blocked_id);
+
+ALTER TABLE users ADD COLUMN group_add_privacy TEXT NOT NULL DEFAULT 'everyone' CHECK (
+    group_add_privacy IN ('everyone', 'contacts', 'nobody')
+);
@@ -0,0 +1,39 @@
+CREATE TABLE IF NOT EXISTS group_invites (
+    invite_id TEXT PRIMARY KEY CHECK (
+        invite_id LIKE '________-____-____-____-____________'
+    ),
+    token_hash TEXT NOT NULL UNIQUE CHECK (
+        LENGTH (token_hash) = 64
+    ),
+    conversation_id TEXT NOT NULL CHECK (
+        conversation_id LIKE '________-____-____-____-____________'
+    ),
+    created_by TEXT CHECK (
+        created_by LIKE '________-____-____-____-____________'
+    ),
+    max_uses INTEGER CHECK (
+        max_uses > 0
+    ),
+    uses INTEGER NOT NULL DEFAULT 0 CHECK (
+        uses >= 0
+    ),
+    created_at TEXT NOT NULL CHECK (
+        created_at LIKE "____-__-__T__:__:__Z" OR
+        created_at LIKE "____-__-__T__:__:__+__:__" OR
+        created_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    expires_at TEXT NOT NULL CHECK (
+        expires_at LIKE "____-__-__T__:__:__Z" OR
+        expires_at LIKE "____-__-__T__:__:__+__:__" OR
+        expires_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    revoked_at TEXT CHECK (
+        revoked_at LIKE "____-__-__T__:__:__Z" OR
+        revoked_at LIKE "____-__-__T__:__:__+__:__" OR
+        revoked_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    FOREIGN KEY (conversation_id) REFERENCES group_conversations (conversation_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_group_invites_conversation_id ON group_invites (conversation_id);
@@ -0,0 +1,6 @@
+DELETE FROM message_trackings WHERE message_id IN (SELECT message_id FROM messages WHERE type = 'system');
+DELETE FROM hidden_messages WHERE message_id IN (SELECT message_id FROM messages WHERE type = 'system');
+DELETE FROM messages WHERE type = 'system';
+ALTER TABLE messages DROP COLUMN target_id;
+ALTER TABLE messages DROP COLUMN event;
+ALTER TABLE messages DROP COLUMN type;
@@ -0,0 +1,18 @@
+ALTER TABLE messages ADD COLUMN type TEXT NOT NULL DEFAULT 'text' CHECK (
+    type IN ('text', 'system')
+);
+
+ALTER TABLE messages ADD COLUMN event TEXT CHECK (
+    event IN (
+        'member_added',
+        'member_joined',
+        'member_left',
+        'member_removed',
+        'group_renamed',
+        'photo_changed'
+    )
+);
+
+ALTER TABLE messages ADD COLUMN target_id TEXT CHECK (
+    target_id LIKE '________-____-____-____-____________'
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_scheduled_messages_conversation_id_sender_id;
+DROP INDEX IF EXISTS idx_scheduled_messages_status_send_at;
+DROP TABLE IF EXISTS scheduled_messages;
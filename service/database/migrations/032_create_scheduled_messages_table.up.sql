@@ -0,0 +1,45 @@
+CREATE TABLE IF NOT EXISTS scheduled_messages (
+    scheduled_message_id TEXT PRIMARY KEY CHECK (
+        scheduled_message_id LIKE '________-____-____-____-____________'
+    ),
+    conversation_id TEXT NOT NULL CHECK (
+        conversation_id LIKE '________-____-____-____-____________'
+    ),
+    sender_id TEXT NOT NULL CHECK (
+        sender_id LIKE '________-____-____-____-____________'
+    ),
+    content TEXT NOT NULL CHECK (
+        LENGTH (content) >= 1
+        AND LENGTH (content) <= 1000
+    ),
+    reply_to_message_id TEXT CHECK (
+        reply_to_message_id LIKE '________-____-____-____-____________'
+    ),
+    created_at TEXT NOT NULL CHECK (
+        created_at LIKE "____-__-__T__:__:__Z" OR
+        created_at LIKE "____-__-__T__:__:__+__:__" OR
+        created_at LIKE "____-__-__T__:__:__-__:__"
+    ),
+    send_at TEXT NOT NULL CHECK (
+        send_at LIKE "____-__-__T__:__:__Z"
+    ),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (
+        status IN ('pending', 'sending', 'failed')
+    ),
+    claimed_until TEXT CHECK (
+        claimed_until LIKE "____-__-__T__:__:__Z"
+    ),
+    failed_at TEXT CHECK (
+        failed_at LIKE "____-__-__T__:__:__Z"
+    ),
+    failure_reason TEXT CHECK (
+        LENGTH (failure_reason) <= 100
+    ),
+    FOREIGN KEY (conversation_id) REFERENCES conversations (conversation_id) ON DELETE CASCADE,
+    FOREIGN KEY (sender_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (reply_to_message_id) REFERENCES messages (message_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_status_send_at ON scheduled_messages (status, send_at);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_messages_conversation_id_sender_id ON scheduled_messages (conversation_id, sender_id);
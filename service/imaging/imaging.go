@@ -0,0 +1,186 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/evaevangelisti/wasatext/service/storage"
+	"github.com/evaevangelisti/wasatext/service/utils"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+var ErrInvalidImage = errors.New("invalid image")
+
+type Variant struct {
+	Size int
+	Crop bool
+}
+
+var (
+	Avatar  = Variant{Size: utils.AvatarThumbnailSize, Crop: true}
+	Preview = Variant{Size: utils.PreviewThumbnailSize}
+)
+
+type Result struct {
+	Image     *storage.Upload
+	Thumbnail *storage.Upload
+}
+
+func Process(upload *storage.Upload, thumbnail Variant) (*Result, error) {
+	img, err := decode(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.ContentType == "image/jpeg" {
+		img = orient(img, jpegOrientation(upload.Data))
+	}
+
+	original, err := encode(fit(img, utils.MaxImageDimension), upload.ContentType == "image/png")
+	if err != nil {
+		return nil, err
+	}
+
+	var thumb image.Image
+	if thumbnail.Crop {
+		thumb = cover(img, thumbnail.Size)
+	} else {
+		thumb = fit(img, thumbnail.Size)
+	}
+
+	thumbnailUpload, err := encode(thumb, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{Image: original, Thumbnail: thumbnailUpload}, nil
+}
+
+func decode(upload *storage.Upload) (image.Image, error) {
+	reader := bytes.NewReader(upload.Data)
+
+	var (
+		config image.Config
+		err    error
+	)
+
+	switch upload.ContentType {
+	case "image/jpeg":
+		config, err = jpeg.DecodeConfig(reader)
+	case "image/png":
+		config, err = png.DecodeConfig(reader)
+	case "image/webp":
+		config, err = webp.DecodeConfig(reader)
+	default:
+		return nil, ErrInvalidImage
+	}
+
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > utils.MaxImagePixels {
+		return nil, ErrInvalidImage
+	}
+
+	if _, err := reader.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	var img image.Image
+
+	switch upload.ContentType {
+	case "image/jpeg":
+		img, err = jpeg.Decode(reader)
+	case "image/png":
+		img, err = png.Decode(reader)
+	case "image/webp":
+		img, err = webp.Decode(reader)
+	}
+
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	return img, nil
+}
+
+func encode(img image.Image, keepPNG bool) (*storage.Upload, error) {
+	var buffer bytes.Buffer
+
+	if keepPNG || !isOpaque(img) {
+		if err := png.Encode(&buffer, img); err != nil {
+			return nil, err
+		}
+
+		return &storage.Upload{Data: buffer.Bytes(), ContentType: "image/png", Extension: utils.ExtPNG}, nil
+	}
+
+	if err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, err
+	}
+
+	return &storage.Upload{Data: buffer.Bytes(), ContentType: "image/jpeg", Extension: utils.ExtJPG}, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+
+	return false
+}
+
+func fit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	return scale(img, bounds, width, height)
+}
+
+func cover(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+
+	return scale(img, image.Rect(x, y, x+side, y+side), min(side, size), min(side, size))
+}
+
+func scale(img image.Image, source image.Rectangle, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, source, draw.Src, nil)
+
+	return dst
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
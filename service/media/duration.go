@@ -0,0 +1,374 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+func Duration(data []byte, contentType string) float64 {
+	var seconds float64
+
+	switch contentType {
+	case "audio/wav":
+		seconds = wavDuration(data)
+	case "audio/mp4", "video/mp4", "video/quicktime":
+		seconds = mp4Duration(data)
+	case "audio/ogg", "video/ogg":
+		seconds = oggDuration(data)
+	case "audio/webm", "video/webm":
+		seconds, _ = scanWebM(data)
+	case "audio/mpeg":
+		seconds = mp3Duration(data)
+	}
+
+	if seconds <= 0 || math.IsInf(seconds, 0) || math.IsNaN(seconds) {
+		return 0
+	}
+
+	return math.Round(seconds*1000) / 1000
+}
+
+func wavDuration(data []byte) float64 {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return 0
+	}
+
+	var byteRate uint32
+
+	for offset := 12; offset+8 <= len(data); {
+		id := string(data[offset : offset+4])
+		size := uint64(binary.LittleEndian.Uint32(data[offset+4:]))
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			if body+12 <= len(data) {
+				byteRate = binary.LittleEndian.Uint32(data[body+8:])
+			}
+
+		case "data":
+			if byteRate == 0 {
+				return 0
+			}
+
+			if size > uint64(len(data)-body) {
+				size = uint64(len(data) - body)
+			}
+
+			return float64(size) / float64(byteRate)
+		}
+
+		if size > uint64(len(data)-body) {
+			return 0
+		}
+
+		offset = body + int(size) + int(size&1)
+	}
+
+	return 0
+}
+
+func mp4Duration(data []byte) float64 {
+	mvhd := findBox(findBox(data, "moov"), "mvhd")
+	if len(mvhd) < 20 {
+		return 0
+	}
+
+	var timescale, duration uint64
+
+	if mvhd[0] == 1 {
+		if len(mvhd) < 32 {
+			return 0
+		}
+
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:]))
+		duration = binary.BigEndian.Uint64(mvhd[24:])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:]))
+	}
+
+	if timescale == 0 {
+		return 0
+	}
+
+	return float64(duration) / float64(timescale)
+}
+
+func findBox(data []byte, name string) []byte {
+	for offset := 0; offset+8 <= len(data); {
+		size := uint64(binary.BigEndian.Uint32(data[offset:]))
+		header := uint64(8)
+
+		switch size {
+		case 0:
+			size = uint64(len(data) - offset)
+		case 1:
+			if offset+16 > len(data) {
+				return nil
+			}
+
+			size = binary.BigEndian.Uint64(data[offset+8:])
+			header = 16
+		}
+
+		if size < header || size > uint64(len(data)-offset) {
+			return nil
+		}
+
+		if string(data[offset+4:offset+8]) == name {
+			return data[offset+int(header) : offset+int(size)]
+		}
+
+		offset += int(size)
+	}
+
+	return nil
+}
+
+func oggDuration(data []byte) float64 {
+	head := data[:min(len(data), 512)]
+
+	var (
+		rate    uint64
+		preSkip uint64
+	)
+
+	if i := bytes.Index(head, []byte("OpusHead")); i >= 0 && i+12 <= len(head) {
+		rate = 48000
+		preSkip = uint64(binary.LittleEndian.Uint16(head[i+10:]))
+	} else if i := bytes.Index(head, []byte("\x01vorbis")); i >= 0 && i+16 <= len(head) {
+		rate = uint64(binary.LittleEndian.Uint32(head[i+12:]))
+	}
+
+	if rate == 0 {
+		return 0
+	}
+
+	for end := len(data); end > 0; {
+		i := bytes.LastIndex(data[:end], []byte("OggS"))
+		if i < 0 || i+14 > len(data) {
+			return 0
+		}
+
+		granule := binary.LittleEndian.Uint64(data[i+6:])
+		if granule != math.MaxUint64 && granule > preSkip {
+			return float64(granule-preSkip) / float64(rate)
+		}
+
+		end = i
+	}
+
+	return 0
+}
+
+var webmMasters = map[uint64]bool{
+	0x18538067: true,
+	0x1549A966: true,
+	0x1654AE6B: true,
+	0xAE:       true,
+	0x1F43B675: true,
+	0xA0:       true,
+}
+
+func webmHasVideo(data []byte) bool {
+	_, hasVideo := scanWebM(data)
+	return hasVideo
+}
+
+func scanWebM(data []byte) (float64, bool) {
+	var (
+		scale                 uint64 = 1000000
+		declared              float64
+		clusterTime, lastTime int64
+		hasVideo              bool
+	)
+
+	for offset := 0; offset < len(data); {
+		id, idLength, _, ok := readVint(data[offset:], true)
+		if !ok {
+			break
+		}
+
+		size, sizeLength, unknown, ok := readVint(data[offset+idLength:], false)
+		if !ok {
+			break
+		}
+
+		body := offset + idLength + sizeLength
+
+		if webmMasters[id] {
+			offset = body
+			continue
+		}
+
+		if unknown {
+			break
+		}
+
+		end := len(data)
+		if size < uint64(len(data)-body) {
+			end = body + int(size)
+		}
+
+		value := data[body:end]
+
+		switch id {
+		case 0x2AD7B1:
+			scale = readUint(value)
+		case 0x4489:
+			declared = readFloat(value)
+		case 0x83:
+			if readUint(value) == 1 {
+				hasVideo = true
+			}
+		case 0xE7:
+			clusterTime = int64(readUint(value))
+		case 0xA3, 0xA1:
+			if _, n, _, ok := readVint(value, false); ok && len(value) >= n+2 {
+				if t := clusterTime + int64(int16(binary.BigEndian.Uint16(value[n:]))); t > lastTime {
+					lastTime = t
+				}
+			}
+		}
+
+		offset = end
+	}
+
+	if declared > 0 {
+		return declared * float64(scale) / 1e9, hasVideo
+	}
+
+	return float64(lastTime) * float64(scale) / 1e9, hasVideo
+}
+
+func readVint(data []byte, keepMarker bool) (uint64, int, bool, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false, false
+	}
+
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+
+	if length > 8 || length > len(data) {
+		return 0, 0, false, false
+	}
+
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+
+	unknown := !keepMarker && value == 1<<(7*uint(length))-1
+
+	return value, length, unknown, true
+}
+
+func readUint(data []byte) uint64 {
+	var value uint64
+
+	for _, b := range data[:min(len(data), 8)] {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	default:
+		return 0
+	}
+}
+
+var (
+	mp3Bitrates = [2][16]int{
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+
+	mp3SampleRates = [4][3]int{
+		{11025, 12000, 8000},
+		{},
+		{22050, 24000, 16000},
+		{44100, 48000, 32000},
+	}
+)
+
+func mp3Duration(data []byte) float64 {
+	offset := 0
+
+	if len(data) >= 10 && string(data[:3]) == "ID3" {
+		offset = 10 + (int(data[6]&0x7F)<<21 | int(data[7]&0x7F)<<14 | int(data[8]&0x7F)<<7 | int(data[9]&0x7F))
+		if data[5]&0x10 != 0 {
+			offset += 10
+		}
+	}
+
+	end := len(data)
+	if end >= 128 && string(data[end-128:end-125]) == "TAG" {
+		end -= 128
+	}
+
+	for ; offset+4 <= end; offset++ {
+		if data[offset] != 0xFF || data[offset+1]&0xE0 != 0xE0 {
+			continue
+		}
+
+		version := int(data[offset+1]>>3) & 3
+		layer := int(data[offset+1]>>1) & 3
+		bitrateIndex := int(data[offset+2] >> 4)
+		rateIndex := int(data[offset+2]>>2) & 3
+
+		if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+			continue
+		}
+
+		table := 1
+		samplesPerFrame := 576
+		if version == 3 {
+			table = 0
+			samplesPerFrame = 1152
+		}
+
+		rate := mp3SampleRates[version][rateIndex]
+		bitrate := mp3Bitrates[table][bitrateIndex] * 1000
+		mono := data[offset+3]>>6 == 3
+
+		sideInfo := 17
+		switch {
+		case version == 3 && !mono:
+			sideInfo = 32
+		case version != 3 && mono:
+			sideInfo = 9
+		}
+
+		if xing := offset + 4 + sideInfo; xing+12 <= end {
+			tag := string(data[xing : xing+4])
+			if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(data[xing+4:])&1 != 0 {
+				frames := binary.BigEndian.Uint32(data[xing+8:])
+				return float64(frames) * float64(samplesPerFrame) / float64(rate)
+			}
+		}
+
+		if vbri := offset + 36; vbri+18 <= end && string(data[vbri:vbri+4]) == "VBRI" {
+			frames := binary.BigEndian.Uint32(data[vbri+14:])
+			return float64(frames) * float64(samplesPerFrame) / float64(rate)
+		}
+
+		return float64(end-offset) * 8 / float64(bitrate)
+	}
+
+	return 0
+}
@@ -0,0 +1,79 @@
+package media
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var durationTests = []struct {
+	file        string
+	contentType string
+	want        float64
+	hasVideo    bool
+}{
+	{file: "tone.wav", contentType: "audio/wav", want: 0.5},
+	{file: "clip.mp4", contentType: "video/mp4", want: 2.5},
+	{file: "clip.mov", contentType: "video/quicktime", want: 3},
+	{file: "voice.opus.ogg", contentType: "audio/ogg", want: 2},
+	{file: "music.vorbis.ogg", contentType: "audio/ogg", want: 1.25},
+	{file: "voice.webm", contentType: "audio/webm", want: 4.2},
+	{file: "clip.webm", contentType: "video/webm", want: 1.5, hasVideo: true},
+	{file: "cbr.mp3", contentType: "audio/mpeg", want: 0.261},
+	{file: "vbr.mp3", contentType: "audio/mpeg", want: 2.612},
+}
+
+func readTestdata(t testing.TB, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestDuration(t *testing.T) {
+	for _, test := range durationTests {
+		t.Run(test.file, func(t *testing.T) {
+			data := readTestdata(t, test.file)
+
+			if got := Duration(data, test.contentType); got != test.want {
+				t.Errorf("Duration(%s) = %v, want %v", test.file, got, test.want)
+			}
+
+			if got := Duration(data[:len(data)/2], test.contentType); got < 0 {
+				t.Errorf("Duration(truncated %s) = %v, want a non-negative value", test.file, got)
+			}
+		})
+	}
+}
+
+func TestWebmHasVideo(t *testing.T) {
+	for _, test := range durationTests {
+		if test.contentType != "audio/webm" && test.contentType != "video/webm" {
+			continue
+		}
+
+		if got := webmHasVideo(readTestdata(t, test.file)); got != test.hasVideo {
+			t.Errorf("webmHasVideo(%s) = %v, want %v", test.file, got, test.hasVideo)
+		}
+	}
+}
+
+func FuzzDuration(f *testing.F) {
+	for _, test := range durationTests {
+		f.Add(readTestdata(f, test.file), test.contentType)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte, contentType string) {
+		got := Duration(data, contentType)
+		if got < 0 || math.IsInf(got, 0) || math.IsNaN(got) {
+			t.Errorf("Duration = %v, want a finite non-negative value", got)
+		}
+
+		webmHasVideo(data)
+	})
+}
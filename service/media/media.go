@@ -0,0 +1,204 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/evaevangelisti/wasatext/service/storage"
+)
+
+const (
+	KindImage    = "image"
+	KindAudio    = "audio"
+	KindVideo    = "video"
+	KindDocument = "document"
+)
+
+const maxFilenameLength = 255
+
+var (
+	ErrUnsupportedType = errors.New("unsupported attachment type")
+	ErrTooLarge        = errors.New("attachment too large")
+)
+
+var extensions = map[string]string{
+	"image/jpeg":                    ".jpg",
+	"image/png":                     ".png",
+	"image/webp":                    ".webp",
+	"audio/mpeg":                    ".mp3",
+	"audio/ogg":                     ".ogg",
+	"audio/wav":                     ".wav",
+	"audio/aiff":                    ".aiff",
+	"audio/mp4":                     ".m4a",
+	"audio/webm":                    ".weba",
+	"video/mp4":                     ".mp4",
+	"video/quicktime":               ".mov",
+	"video/webm":                    ".webm",
+	"video/ogg":                     ".ogv",
+	"video/avi":                     ".avi",
+	"application/pdf":               ".pdf",
+	"application/zip":               ".zip",
+	"application/x-gzip":            ".gz",
+	"application/msword":            ".doc",
+	"application/vnd.ms-excel":      ".xls",
+	"application/vnd.ms-powerpoint": ".ppt",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/vnd.oasis.opendocument.presentation":                           ".odp",
+	"text/plain": ".txt",
+	"text/csv":   ".csv",
+}
+
+var refinements = map[string]map[string]string{
+	"application/zip": {
+		".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+		".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+		".odt":  "application/vnd.oasis.opendocument.text",
+		".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+		".odp":  "application/vnd.oasis.opendocument.presentation",
+	},
+	"application/x-ole-storage": {
+		".doc": "application/msword",
+		".xls": "application/vnd.ms-excel",
+		".ppt": "application/vnd.ms-powerpoint",
+	},
+	"text/plain": {
+		".csv": "text/csv",
+	},
+}
+
+type File struct {
+	Upload   *storage.Upload
+	Kind     string
+	Filename string
+	Duration float64
+}
+
+func Inspect(data []byte, filename string) (*File, error) {
+	contentType := detectContentType(data, strings.ToLower(filepath.Ext(filename)))
+
+	extension, ok := extensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	file := &File{
+		Upload:   &storage.Upload{Data: data, ContentType: contentType, Extension: extension},
+		Kind:     KindOf(contentType),
+		Filename: sanitizeFilename(filename, extension),
+	}
+
+	if file.Kind == KindAudio || file.Kind == KindVideo {
+		file.Duration = Duration(data, contentType)
+	}
+
+	return file, nil
+}
+
+func KindOf(contentType string) string {
+	switch {
+	case contentType == "image/jpeg" || contentType == "image/png" || contentType == "image/webp":
+		return KindImage
+	case strings.HasPrefix(contentType, "audio/"):
+		return KindAudio
+	case strings.HasPrefix(contentType, "video/"):
+		return KindVideo
+	default:
+		return KindDocument
+	}
+}
+
+func detectContentType(data []byte, extension string) string {
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+
+	switch {
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		switch string(data[8:12]) {
+		case "M4A ", "M4B ":
+			contentType = "audio/mp4"
+		case "qt  ":
+			contentType = "video/quicktime"
+		default:
+			contentType = "video/mp4"
+		}
+
+	case contentType == "application/ogg":
+		contentType = "audio/ogg"
+		if bytes.Contains(data[:min(len(data), 512)], []byte("\x80theora")) {
+			contentType = "video/ogg"
+		}
+
+	case contentType == "video/webm":
+		if !webmHasVideo(data) {
+			contentType = "audio/webm"
+		}
+
+	case contentType == "audio/wave":
+		contentType = "audio/wav"
+
+	case contentType == "application/octet-stream" && len(data) >= 2 && data[0] == 0xFF && data[1]&0xE6 == 0xE2:
+		contentType = "audio/mpeg"
+
+	case contentType == "application/octet-stream" && bytes.HasPrefix(data, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")):
+		contentType = "application/x-ole-storage"
+	}
+
+	if refined, ok := refinements[contentType][extension]; ok {
+		return refined
+	}
+
+	return contentType
+}
+
+func sanitizeFilename(filename, extension string) string {
+	filename = strings.TrimSpace(filepath.Base(strings.ReplaceAll(filename, "\\", "/")))
+
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '/' {
+			return -1
+		}
+
+		return r
+	}, filename)
+
+	if filename == "" || filename == "." || filename == ".." {
+		filename = "attachment" + extension
+	}
+
+	if len(filename) > maxFilenameLength {
+		suffix := filepath.Ext(filename)
+		if len(suffix) > len(extension)+8 {
+			suffix = ""
+		}
+
+		base := strings.TrimSuffix(filename, suffix)[:maxFilenameLength-len(suffix)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+
+		filename = base + suffix
+	}
+
+	return filename
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,49 @@
+package media
+
+import "strings"
+
+type Policy struct {
+	AllowedTypes []string
+	MaxSizes     map[string]int64
+	MaxTotalSize int64
+}
+
+func (policy Policy) MaxSize() int64 {
+	var maxSize int64
+
+	for _, size := range policy.MaxSizes {
+		if size > maxSize {
+			maxSize = size
+		}
+	}
+
+	return maxSize
+}
+
+func (policy Policy) Check(file *File) error {
+	if !policy.allows(file.Upload.ContentType) {
+		return ErrUnsupportedType
+	}
+
+	if int64(len(file.Upload.Data)) > policy.MaxSizes[file.Kind] {
+		return ErrTooLarge
+	}
+
+	return nil
+}
+
+func (policy Policy) allows(contentType string) bool {
+	for _, allowed := range policy.AllowedTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+
+		if allowed == contentType {
+			return true
+		}
+
+		if strings.HasSuffix(allowed, "*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
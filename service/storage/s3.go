@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+)
+
+const (
+	s3Algorithm   = "AWS4-HMAC-SHA256"
+	s3Service     = "s3"
+	s3DateFormat  = "20060102T150405Z"
+	s3ShortFormat = "20060102"
+)
+
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Prefix          string
+}
+
+type S3Store struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("s3 endpoint and bucket are required")
+	}
+
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("s3 credentials are required")
+	}
+
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", config.Endpoint)
+	}
+
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	return &S3Store{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (store *S3Store) objectURL(key string) (*url.URL, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	if store.config.Prefix != "" {
+		key = store.config.Prefix + "/" + key
+	}
+
+	objectURL := *store.endpoint
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + store.config.Bucket + "/" + key
+	objectURL.RawPath = ""
+
+	return &objectURL, nil
+}
+
+func (store *S3Store) Put(key string, data io.Reader, _ int64, contentType string) error {
+	body, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	response, err := store.do(http.MethodPut, key, body, map[string]string{"Content-Type": contentType})
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return s3Error(http.MethodPut, key, response)
+	}
+
+	return nil
+}
+
+func (store *S3Store) Get(key string) (*Blob, error) {
+	response, err := store.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrNotFound
+	}
+
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, s3Error(http.MethodGet, key, response)
+	}
+
+	blob := &Blob{
+		ReadCloser:  response.Body,
+		ContentType: response.Header.Get("Content-Type"),
+		Size:        response.ContentLength,
+	}
+
+	if lastModified, err := http.ParseTime(response.Header.Get("Last-Modified")); err == nil {
+		blob.LastModified = lastModified
+	}
+
+	return blob, nil
+}
+
+func (store *S3Store) Delete(key string) error {
+	response, err := store.do(http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK {
+		return s3Error(http.MethodDelete, key, response)
+	}
+
+	return nil
+}
+
+func (store *S3Store) do(method, key string, body []byte, headers map[string]string) (*http.Response, error) {
+	objectURL, err := store.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	request.ContentLength = int64(len(body))
+
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	store.sign(request, body, globaltime.Now())
+
+	return store.client.Do(request)
+}
+
+func (store *S3Store) sign(request *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	date := now.Format(s3ShortFormat)
+
+	payloadHash := sha256.Sum256(body)
+
+	request.Header.Set("Host", request.URL.Host)
+	request.Header.Set("X-Amz-Date", now.Format(s3DateFormat))
+	request.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	signedHeaderNames := []string{}
+	canonicalHeaders := map[string]string{}
+
+	for name, values := range request.Header {
+		lower := strings.ToLower(name)
+		signedHeaderNames = append(signedHeaderNames, lower)
+		canonicalHeaders[lower] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	sort.Strings(signedHeaderNames)
+
+	var headerBlock strings.Builder
+
+	for _, name := range signedHeaderNames {
+		headerBlock.WriteString(name + ":" + canonicalHeaders[name] + "\n")
+	}
+
+	signedHeaders := strings.Join(signedHeaderNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.Query().Encode(),
+		headerBlock.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := date + "/" + store.config.Region + "/" + s3Service + "/aws4_request"
+
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3DateFormat),
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+store.config.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, store.config.Region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", s3Algorithm+" Credential="+store.config.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func s3Error(method, key string, response *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+
+	return fmt.Errorf("s3 %s %s: %d: %s", method, key, response.StatusCode, strings.TrimSpace(string(message)))
+}
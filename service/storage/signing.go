@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/evaevangelisti/wasatext/service/utils/globaltime"
+)
+
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+type URLSigner struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewURLSigner(secret []byte, ttl time.Duration) (*URLSigner, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("signing secret is required")
+	}
+
+	if ttl <= 0 {
+		return nil, fmt.Errorf("signed URL TTL must be positive")
+	}
+
+	return &URLSigner{secret: secret, ttl: ttl}, nil
+}
+
+func (signer *URLSigner) Sign(rawURL string) string {
+	key, ok := KeyFromURL(rawURL)
+	if !ok {
+		return rawURL
+	}
+
+	expires := globaltime.Now().Truncate(signer.ttl / 2).Add(signer.ttl).Unix()
+
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", signer.signature(key, expires))
+
+	return rawURL + "?" + query.Encode()
+}
+
+func (signer *URLSigner) Verify(key, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if globaltime.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(signer.signature(key, expiresAt))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (signer *URLSigner) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, signer.secret)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
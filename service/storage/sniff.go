@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/evaevangelisti/wasatext/service/utils"
+)
+
+var ErrUnsupportedType = errors.New("unsupported content type")
+
+var imageExtensions = map[string]string{
+	"image/jpeg": utils.ExtJPG,
+	"image/png":  utils.ExtPNG,
+	"image/webp": utils.ExtWEBP,
+}
+
+type Upload struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+}
+
+func NewImageUpload(data []byte) (*Upload, error) {
+	contentType := http.DetectContentType(data)
+
+	extension, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	return &Upload{Data: data, ContentType: contentType, Extension: extension}, nil
+}
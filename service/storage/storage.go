@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ProfilePicturesDir = "profile-pictures"
+	GroupPhotosDir     = "group-photos"
+	AttachmentsDir     = "attachments"
+)
+
+const urlPrefix = "/uploads/"
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+type BlobStore interface {
+	Put(key string, data io.Reader, size int64, contentType string) error
+	Get(key string) (*Blob, error)
+	Delete(key string) error
+}
+
+type Blob struct {
+	io.ReadCloser
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
+
+func URL(key string) string {
+	return urlPrefix + key
+}
+
+func KeyFromURL(url string) (string, bool) {
+	if !strings.HasPrefix(url, urlPrefix) {
+		return "", false
+	}
+
+	key := strings.TrimPrefix(url, urlPrefix)
+
+	if err := validateKey(key); err != nil {
+		return "", false
+	}
+
+	return key, true
+}
+
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+
+	return nil
+}
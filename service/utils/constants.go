@@ -1,8 +1,97 @@
 package utils
 
+import "time"
+
 const (
 	ExtJPG  = ".jpg"
 	ExtJPEG = ".jpeg"
 	ExtPNG  = ".png"
 	ExtWEBP = ".webp"
 )
+
+const (
+	MaxImageSize         = 5 << 20
+	MaxImagePixels       = 40000000
+	MaxImageDimension    = 2048
+	AvatarThumbnailSize  = 128
+	PreviewThumbnailSize = 480
+	MultipartOverhead    = 1 << 20
+)
+
+const MaxMessageAttachments = 10
+
+const MessagesPageDefaultLimit = 50
+
+const MessagePreviewSnippetLength = 100
+
+const MaxPinnedMessages = 10
+
+const MaxPinnedConversations = 5
+
+const MaxGroupMembers = 100
+
+const (
+	GroupInviteDefaultTTL = 7 * 24 * time.Hour
+	GroupInviteMaxTTL     = 30 * 24 * time.Hour
+)
+
+const (
+	MaxScheduledMessages     = 50
+	ScheduledMessageMaxDelay = 365 * 24 * time.Hour
+	ScheduledMessagesBatch   = 100
+	ScheduledMessageLease    = time.Minute
+)
+
+const (
+	ScheduledMessageStatusPending = "pending"
+	ScheduledMessageStatusSending = "sending"
+	ScheduledMessageStatusFailed  = "failed"
+)
+
+const (
+	MaxForwardedMessages    = 50
+	MaxForwardConversations = 20
+)
+
+const (
+	MessageTypeText   = "text"
+	MessageTypeSystem = "system"
+)
+
+const (
+	SystemEventMemberAdded   = "member_added"
+	SystemEventMemberJoined  = "member_joined"
+	SystemEventMemberLeft    = "member_left"
+	SystemEventMemberRemoved = "member_removed"
+	SystemEventGroupRenamed  = "group_renamed"
+	SystemEventPhotoChanged  = "photo_changed"
+)
+
+const (
+	DeleteScopeEveryone = "everyone"
+	DeleteScopeMe       = "me"
+)
+
+const (
+	PasswordMinLength      = 8
+	PasswordMaxLength      = 72
+	MaxFailedLoginAttempts = 5
+	LoginLockoutDuration   = 15 * time.Minute
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+const (
+	PermissionMembers = "members"
+	PermissionAdmins  = "admins"
+)
+
+const (
+	GroupAddEveryone = "everyone"
+	GroupAddContacts = "contacts"
+	GroupAddNobody   = "nobody"
+)
@@ -0,0 +1,61 @@
+package emoji
+
+import "testing"
+
+func TestIsEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "empty", input: "", want: false},
+		{name: "invalid utf-8", input: "\xff", want: false},
+		{name: "plain text", input: "a", want: false},
+		{name: "two emoji", input: "👍👍", want: false},
+		{name: "thumbs up", input: "👍", want: true},
+		{name: "face with tears of joy", input: "😂", want: true},
+		{name: "presentation with redundant selector", input: "😮️", want: true},
+		{name: "skin tone", input: "👍🏽", want: true},
+		{name: "bare skin tone", input: "🏽", want: false},
+		{name: "red heart", input: "❤️", want: true},
+		{name: "text-style heart", input: "❤", want: false},
+		{name: "copyright with selector", input: "©️", want: true},
+		{name: "text-style copyright", input: "©", want: false},
+		{name: "text-style registered", input: "®", want: false},
+		{name: "text-style sun", input: "☀", want: false},
+		{name: "sun with selector", input: "☀️", want: true},
+		{name: "index pointing up with skin tone", input: "☝🏻", want: true},
+		{name: "high voltage", input: "⚡", want: true},
+		{name: "check mark button", input: "✅", want: true},
+		{name: "dingbat", input: "✁", want: false},
+		{name: "dingbat with selector", input: "✁️", want: false},
+		{name: "chess symbol", input: "♔", want: false},
+		{name: "mahjong red dragon", input: "🀄", want: true},
+		{name: "mahjong tile", input: "🀀", want: false},
+		{name: "domino tile", input: "🀰", want: false},
+		{name: "playing card", input: "🂡", want: false},
+		{name: "joker", input: "🃏", want: true},
+		{name: "flag", input: "🇮🇹", want: true},
+		{name: "bare regional indicator", input: "🇮", want: false},
+		{name: "three regional indicators", input: "🇮🇹🇮", want: false},
+		{name: "keycap", input: "1️⃣", want: true},
+		{name: "digit", input: "1", want: false},
+		{name: "family", input: "👨‍👩‍👧", want: true},
+		{name: "heart on fire", input: "❤️‍🔥", want: true},
+		{name: "rainbow flag", input: "🏳️‍🌈", want: true},
+		{name: "health worker", input: "🧑‍⚕️", want: true},
+		{name: "trailing joiner", input: "👍‍", want: false},
+		{name: "leading joiner", input: "‍👍", want: false},
+		{name: "england", input: "🏴\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F", want: true},
+		{name: "unterminated tag sequence", input: "🏴\U000E0067\U000E0062", want: false},
+		{name: "too long", input: "👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍‍👍", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsEmoji(test.input); got != test.want {
+				t.Errorf("IsEmoji(%q) = %v, want %v", test.input, got, test.want)
+			}
+		})
+	}
+}
@@ -20,12 +20,15 @@ func New(message string, status int) *Error {
 }
 
 var (
-	ErrBadRequest   = New("Bad request", http.StatusBadRequest)
-	ErrUnauthorized = New("Unauthorized access", http.StatusUnauthorized)
-	ErrForbidden    = New("Forbidden access", http.StatusForbidden)
-	ErrNotFound     = New("Resource not found", http.StatusNotFound)
-	ErrConflict     = New("Conflict error", http.StatusConflict)
-	ErrInternal     = New("Internal server error", http.StatusInternalServerError)
+	ErrBadRequest      = New("Bad request", http.StatusBadRequest)
+	ErrUnauthorized    = New("Unauthorized access", http.StatusUnauthorized)
+	ErrForbidden       = New("Forbidden access", http.StatusForbidden)
+	ErrNotFound        = New("Resource not found", http.StatusNotFound)
+	ErrConflict        = New("Conflict error", http.StatusConflict)
+	ErrPayloadTooLarge = New("Payload too large", http.StatusRequestEntityTooLarge)
+	ErrUnsupportedType = New("Unsupported media type", http.StatusUnsupportedMediaType)
+	ErrTooManyRequests = New("Too many requests", http.StatusTooManyRequests)
+	ErrInternal        = New("Internal server error", http.StatusInternalServerError)
 )
 
 func WriteHTTPError(w http.ResponseWriter, err error) {
@@ -0,0 +1,117 @@
+import api from "@/services/api";
+
+const listeners = new Set();
+
+let controller = null;
+let reconnectTimeout = null;
+let reconnectDelay = 1000;
+
+function dispatch(event) {
+  for (const listener of listeners) {
+    try {
+      listener(event);
+    } catch (e) {
+      console.error(e);
+    }
+  }
+}
+
+function parseChunk(chunk) {
+  let data = "";
+
+  for (const line of chunk.split("\n")) {
+    if (line.startsWith("data:")) {
+      data += line.slice(5).trimStart();
+    }
+  }
+
+  if (!data) return;
+
+  try {
+    dispatch(JSON.parse(data));
+  } catch (e) {
+    console.error(e);
+  }
+}
+
+function scheduleReconnect() {
+  if (reconnectTimeout || listeners.size === 0) return;
+
+  reconnectTimeout = setTimeout(() => {
+    reconnectTimeout = null;
+    connect();
+  }, reconnectDelay);
+
+  reconnectDelay = Math.min(reconnectDelay * 2, 30000);
+}
+
+async function connect() {
+  const authorization = api.defaults.headers.common["Authorization"];
+  if (controller || !authorization) return;
+
+  controller = new AbortController();
+  const signal = controller.signal;
+
+  try {
+    const response = await fetch(api.defaults.baseURL.replace(/\/$/, "") + "/events", {
+      headers: { Authorization: authorization, Accept: "text/event-stream" },
+      signal,
+    });
+
+    if (!response.ok || !response.body) {
+      throw new Error(`event stream failed with status ${response.status}`);
+    }
+
+    reconnectDelay = 1000;
+
+    const reader = response.body.getReader();
+    const decoder = new TextDecoder();
+    let buffer = "";
+
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) break;
+
+      buffer += decoder.decode(value, { stream: true });
+
+      let separator;
+      while ((separator = buffer.indexOf("\n\n")) !== -1) {
+        parseChunk(buffer.slice(0, separator));
+        buffer = buffer.slice(separator + 2);
+      }
+    }
+  } catch (e) {
+    if (signal.aborted) return;
+    console.error(e);
+  } finally {
+    if (controller?.signal === signal) {
+      controller = null;
+    }
+  }
+
+  if (!signal.aborted) {
+    scheduleReconnect();
+  }
+}
+
+export function subscribe(listener) {
+  listeners.add(listener);
+  connect();
+
+  return () => {
+    listeners.delete(listener);
+    if (listeners.size === 0) disconnect();
+  };
+}
+
+export function disconnect() {
+  if (reconnectTimeout) {
+    clearTimeout(reconnectTimeout);
+    reconnectTimeout = null;
+  }
+
+  if (controller) {
+    controller.abort();
+    controller = null;
+  }
+}
@@ -0,0 +1,26 @@
+function displayName(user, userId, self) {
+  if (!user) return "Someone";
+  return user.userId === userId ? self : user.username;
+}
+
+export function describeSystemMessage(message, userId) {
+  const actor = displayName(message.actor, userId, "You");
+  const target = displayName(message.target, userId, "you");
+
+  switch (message.event) {
+    case "member_added":
+      return `${actor} added ${target}`;
+    case "member_joined":
+      return `${actor} joined using an invite link`;
+    case "member_left":
+      return `${actor} left`;
+    case "member_removed":
+      return `${actor} removed ${target}`;
+    case "group_renamed":
+      return `${actor} renamed the group to "${message.content}"`;
+    case "photo_changed":
+      return `${actor} changed the group photo`;
+    default:
+      return "";
+  }
+}